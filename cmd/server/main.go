@@ -4,8 +4,11 @@ import (
 	"fitness-market/internal/database"
 	"fitness-market/internal/handlers"
 	"fitness-market/internal/middleware"
+	"fitness-market/internal/scheduler"
+	"fitness-market/internal/services"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -23,6 +26,44 @@ func main() {
 	// Run migrations
 	database.RunMigrations()
 
+	// Fail imports a previous run left unfinished
+	if err := services.FailInterruptedImports(); err != nil {
+		log.Printf("Failed to mark interrupted imports: %v", err)
+	}
+
+	// Finish rescores a previous run left unfinished
+	if err := services.ResumeRescoreJobs(); err != nil {
+		log.Printf("Failed to resume rescore jobs: %v", err)
+	}
+
+	// Start background jobs
+	scheduler.Register(scheduler.Job{
+		Name:     "portfolio-snapshots",
+		Interval: time.Hour,
+		Run:      services.GenerateDueSnapshots,
+	})
+	scheduler.Register(scheduler.Job{
+		Name:     "market-indices",
+		Interval: time.Hour,
+		Run:      services.RecordDueIndices,
+	})
+	scheduler.Register(scheduler.Job{
+		Name:     "dividends",
+		Interval: time.Hour,
+		Run:      services.PayDueDividends,
+	})
+	scheduler.Register(scheduler.Job{
+		Name:     "stale-ticker-alerts",
+		Interval: time.Hour,
+		Run:      services.EvaluateStaleAlerts,
+	})
+	scheduler.Register(scheduler.Job{
+		Name:     "idempotency-keys",
+		Interval: time.Hour,
+		Run:      services.DeleteExpiredIdempotencyKeys,
+	})
+	scheduler.Start()
+
 	// Setup Gin router
 	r := gin.Default()
 
@@ -30,7 +71,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -58,10 +99,13 @@ func main() {
 	api.Use(middleware.AuthMiddleware())
 	{
 		api.GET("/profile", handlers.GetUserProfile)
+		api.PUT("/profile", handlers.UpdateUserProfile)
 
 		// Bodyweight routes
-		api.POST("/profile/bodyweight", handlers.AddBodyweight)
+		api.POST("/profile/bodyweight", middleware.Idempotency(), handlers.AddBodyweight)
 		api.GET("/profile/bodyweight", handlers.GetBodyweightHistory)
+		api.PUT("/profile/bodyweight/:id", handlers.UpdateBodyweight)
+		api.DELETE("/profile/bodyweight/:id", handlers.DeleteBodyweight)
 
 		// Exercise PR routes (legacy)
 		api.POST("/profile/exercise-prs", handlers.AddExercisePR)
@@ -70,13 +114,93 @@ func main() {
 		api.DELETE("/profile/exercise-prs/:id", handlers.DeleteExercisePR)
 
 		// Exercise CRUD routes
-		api.POST("/exercises", handlers.CreateExercise)
+		api.POST("/exercises", middleware.Idempotency(), handlers.CreateExercise)
 		api.GET("/exercises", handlers.GetExercises)
 		api.PUT("/exercises/:id", handlers.UpdateExercise)
 		api.DELETE("/exercises/:id", handlers.DeleteExercise)
+		api.GET("/exercises/:id/prices", handlers.GetExercisePriceHistory)
+		api.GET("/exercises/:id/candles", handlers.GetExerciseCandles)
+		api.GET("/exercises/:id/indicators", handlers.GetExerciseIndicators)
+		api.GET("/exercises/:id/suggestion", handlers.GetExerciseSuggestion)
+		api.PUT("/exercises/:id/price", handlers.OverrideExercisePrice)
+		api.POST("/exercises/:id/split", handlers.SplitExercise)
+		api.GET("/exercises/:id/corporate-actions", handlers.GetExerciseCorporateActions)
+
+		// Scoring routes
+		api.GET("/scoring/strategies", handlers.GetScoringStrategies)
+
+		// Catalog routes
+		api.GET("/catalog", handlers.GetCatalog)
+		api.GET("/catalog/:id", handlers.GetCatalogExercise)
 
 		// Workout entry routes
-		api.POST("/entries", handlers.CreateEntry)
+		api.POST("/entries", middleware.Idempotency(), handlers.CreateEntry)
+		api.GET("/entries", handlers.ListEntries)
+		api.GET("/entries/:id", handlers.GetEntry)
+		api.PUT("/entries/:id", handlers.UpdateEntry)
+		api.DELETE("/entries/:id", handlers.DeleteEntry)
+
+		// Workout session routes
+		api.POST("/sessions", handlers.StartSession)
+		api.GET("/sessions", handlers.GetSessions)
+		api.GET("/sessions/:id", handlers.GetSession)
+		api.POST("/sessions/:id/finish", handlers.FinishSession)
+
+		// Routine routes
+		api.GET("/routines", handlers.GetRoutines)
+		api.POST("/routines", handlers.CreateRoutine)
+		api.GET("/routines/:id", handlers.GetRoutine)
+		api.PUT("/routines/:id", handlers.UpdateRoutine)
+		api.DELETE("/routines/:id", handlers.DeleteRoutine)
+		api.POST("/routines/:id/start", handlers.StartRoutine)
+
+		// Import routes
+		api.POST("/imports", handlers.CreateImport)
+		api.GET("/imports", handlers.GetImports)
+		api.GET("/imports/:id", handlers.GetImport)
+
+		// Rescore job routes
+		api.GET("/rescores", handlers.GetRescoreJobs)
+		api.GET("/rescores/:id", handlers.GetRescoreJob)
+
+		// Data export routes
+		api.GET("/export", handlers.ExportData)
+
+		// Offline sync routes
+		api.GET("/sync", handlers.GetSync)
+		api.POST("/sync", handlers.PostSync)
+
+		// Portfolio routes
+		api.GET("/portfolio", handlers.GetPortfolio)
+		api.GET("/portfolio/history", handlers.GetPortfolioHistory)
+		api.GET("/portfolio/dividends", handlers.GetPortfolioDividends)
+		api.GET("/portfolio/cash", handlers.GetPortfolioCash)
+
+		// Trading routes
+		api.POST("/trades", handlers.CreateTrade)
+		api.GET("/trades", handlers.GetTrades)
+		api.GET("/holdings", handlers.GetHoldings)
+
+		// Watchlist routes
+		api.GET("/watchlists", handlers.GetWatchlists)
+		api.POST("/watchlists", handlers.CreateWatchlist)
+		api.GET("/watchlists/:id", handlers.GetWatchlist)
+		api.DELETE("/watchlists/:id", handlers.DeleteWatchlist)
+		api.POST("/watchlists/:id/items", handlers.AddWatchlistItem)
+		api.DELETE("/watchlists/:id/items/:exercise_id", handlers.RemoveWatchlistItem)
+
+		// Alert and notification routes
+		api.GET("/alerts", handlers.GetAlerts)
+		api.POST("/alerts", handlers.CreateAlert)
+		api.PUT("/alerts/:id", handlers.UpdateAlert)
+		api.DELETE("/alerts/:id", handlers.DeleteAlert)
+		api.GET("/notifications", handlers.GetNotifications)
+		api.PUT("/notifications/read-all", handlers.MarkAllNotificationsRead)
+		api.PUT("/notifications/:id/read", handlers.MarkNotificationRead)
+
+		// Market index routes
+		api.GET("/indices", handlers.GetIndices)
+		api.GET("/indices/:symbol/history", handlers.GetIndexHistory)
 
 		// PR History routes
 		api.GET("/prs", handlers.GetAllUserPRs)
@@ -0,0 +1,67 @@
+// Package catalog bundles the curated global exercise catalog and seeds it into the database
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:embed exercises.json
+var exercisesJSON []byte
+
+// Entry is one exercise in the bundled catalog data file
+type Entry struct {
+	Ticker           string   `json:"ticker"`
+	Name             string   `json:"name"`
+	Category         string   `json:"category"`
+	PrimaryMuscles   []string `json:"primary_muscles"`
+	SecondaryMuscles []string `json:"secondary_muscles"`
+	Equipment        string   `json:"equipment"`
+	MeasurementType  string   `json:"measurement_type"`
+	Description      string   `json:"description"`
+}
+
+// Entries returns the bundled catalog
+func Entries() ([]Entry, error) {
+	var entries []Entry
+	if err := json.Unmarshal(exercisesJSON, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Seed inserts the bundled catalog, updating existing entries matched by ticker so edits to
+// the data file are picked up on the next start
+func Seed(db *gorm.DB) error {
+	entries, err := Entries()
+	if err != nil {
+		return err
+	}
+
+	exercises := make([]models.CatalogExercise, 0, len(entries))
+	for _, entry := range entries {
+		exercises = append(exercises, models.CatalogExercise{
+			Ticker:           entry.Ticker,
+			Name:             entry.Name,
+			Description:      entry.Description,
+			Category:         entry.Category,
+			PrimaryMuscles:   entry.PrimaryMuscles,
+			SecondaryMuscles: entry.SecondaryMuscles,
+			Equipment:        entry.Equipment,
+			MeasurementType:  entry.MeasurementType,
+		})
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "ticker"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "description", "category", "primary_muscles", "secondary_muscles", "equipment",
+			"measurement_type", "updated_at",
+		}),
+	}).Create(&exercises).Error
+}
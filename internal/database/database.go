@@ -35,9 +35,11 @@ func Init() {
 func AutoMigrate() {
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.CatalogExercise{},
 		&models.Exercise{},
 		&models.WorkoutEntry{},
 		&models.PortfolioSnapshot{},
+		&models.IndexValue{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
@@ -54,6 +56,7 @@ func createIndexes() {
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_workout_entries_user_date ON workout_entries(user_id, date)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_workout_entries_exercise_date ON workout_entries(exercise_id, date)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_portfolio_snapshots_user_date ON portfolio_snapshots(user_id, date)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_index_values_user_symbol_date ON index_values(user_id, symbol, date)")
 
 	log.Println("Database indexes created")
 }
@@ -69,4 +72,4 @@ func Close() {
 			sqlDB.Close()
 		}
 	}
-}
\ No newline at end of file
+}
@@ -1,8 +1,12 @@
 package database
 
 import (
+	"fitness-market/internal/catalog"
 	"fitness-market/internal/models"
 	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func RunMigrations() {
@@ -14,13 +18,109 @@ func RunMigrations() {
 		&models.BodyweightEntry{},
 		&models.ExercisePR{},
 		&models.Exercise{},
+		&models.WorkoutSession{},
 		&models.WorkoutEntry{},
+		&models.WorkoutSet{},
 		&models.PRHistory{},
+		&models.PriceHistory{},
+		&models.CashLedgerEntry{},
+		&models.Dividend{},
+		&models.CorporateAction{},
+		&models.Holding{},
+		&models.Trade{},
+		&models.Watchlist{},
+		&models.WatchlistItem{},
+		&models.AlertRule{},
+		&models.Notification{},
+		&models.ImportJob{},
+		&models.RescoreJob{},
+		&models.IdempotencyKey{},
+		&models.Routine{},
+		&models.RoutineExercise{},
 	)
 
 	if err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// PR history rows from before record types existed are score records
+	err = DB.Model(&models.PRHistory{}).
+		Where("type = ? AND value = 0", "score").
+		UpdateColumn("value", gorm.Expr("score")).Error
+	if err != nil {
+		log.Fatalf("Failed to backfill PR values: %v", err)
+	}
+
+	if err := backfillWorkoutSets(); err != nil {
+		log.Fatalf("Failed to backfill workout sets: %v", err)
+	}
+
+	if err := backfillUUIDs(); err != nil {
+		log.Fatalf("Failed to backfill UUIDs: %v", err)
+	}
+
+	if err := catalog.Seed(DB); err != nil {
+		log.Fatalf("Failed to seed exercise catalog: %v", err)
+	}
+
 	log.Println("Migrations completed successfully")
 }
+
+// backfillWorkoutSets splits entries logged before per-set logging into their N identical
+// working sets
+func backfillWorkoutSets() error {
+	var entries []models.WorkoutEntry
+	err := DB.Where("NOT EXISTS (SELECT 1 FROM workout_sets WHERE workout_sets.workout_entry_id = workout_entries.id)").
+		Find(&entries).Error
+	if err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			sets := make([]models.WorkoutSet, 0, max(entry.Sets, 1))
+			for i := 0; i < max(entry.Sets, 1); i++ {
+				sets = append(sets, models.WorkoutSet{
+					WorkoutEntryID: entry.ID,
+					SetIndex:       i + 1,
+					Type:           "working",
+					Weight:         entry.Weight,
+					Reps:           entry.Reps,
+					Duration:       entry.Duration,
+					Distance:       entry.Distance,
+				})
+			}
+			if err := tx.Create(&sets).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillUUIDs gives the synced records created before sync existed a UUID, soft-deleted
+// ones included so their deletion can still be synced
+func backfillUUIDs() error {
+	for _, table := range []string{"exercises", "workout_entries", "bodyweight_entries"} {
+		var ids []uint
+		if err := DB.Table(table).Where("uuid IS NULL OR uuid = ''").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for _, id := range ids {
+				if err := tx.Table(table).Where("id = ?", id).UpdateColumn("uuid", uuid.NewString()).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
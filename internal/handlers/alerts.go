@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"fitness-market/internal/models"
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateAlertRequest struct {
+	ExerciseID uint    `json:"exercise_id" binding:"required"`
+	Type       string  `json:"type" binding:"required"`
+	Threshold  float64 `json:"threshold"`
+	WindowDays int     `json:"window_days"`
+}
+
+type UpdateAlertRequest struct {
+	Threshold  *float64 `json:"threshold"`
+	WindowDays *int     `json:"window_days"`
+	Active     *bool    `json:"active"`
+}
+
+// isAlertValidationError reports whether err is a rule validation failure
+func isAlertValidationError(err error) bool {
+	return errors.Is(err, services.ErrInvalidAlertType) ||
+		errors.Is(err, services.ErrInvalidAlertThreshold) ||
+		errors.Is(err, services.ErrInvalidAlertWindow)
+}
+
+// GetAlerts handles GET /api/v1/alerts
+func GetAlerts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var exerciseID uint64
+	if exerciseIDStr := c.Query("exercise_id"); exerciseIDStr != "" {
+		var err error
+		exerciseID, err = strconv.ParseUint(exerciseIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+			return
+		}
+	}
+
+	rules, err := services.GetAlertRules(userID.(uint), uint(exerciseID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": rules,
+		"count":  len(rules),
+	})
+}
+
+// CreateAlert handles POST /api/v1/alerts
+func CreateAlert(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.AlertRule{
+		UserID:     userID.(uint),
+		ExerciseID: req.ExerciseID,
+		Type:       req.Type,
+		Threshold:  req.Threshold,
+		WindowDays: req.WindowDays,
+	}
+	if err := services.CreateAlertRule(&rule); err != nil {
+		switch {
+		case isAlertValidationError(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alert"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Alert created successfully",
+		"alert":   rule,
+	})
+}
+
+// UpdateAlert handles PUT /api/v1/alerts/:id
+func UpdateAlert(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	var req UpdateAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.GetAlertRule(userID.(uint), uint(ruleID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.WindowDays != nil {
+		rule.WindowDays = *req.WindowDays
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+
+	if err := services.UpdateAlertRule(rule); err != nil {
+		if isAlertValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert updated successfully",
+		"alert":   rule,
+	})
+}
+
+// DeleteAlert handles DELETE /api/v1/alerts/:id
+func DeleteAlert(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	if err := services.DeleteAlertRule(userID.(uint), uint(ruleID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert deleted successfully"})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultCandleRanges is how far back candles go when no from date is given
+var defaultCandleRanges = map[string]time.Duration{
+	services.CandleIntervalDay:   90 * 24 * time.Hour,
+	services.CandleIntervalWeek:  365 * 24 * time.Hour,
+	services.CandleIntervalMonth: 2 * 365 * 24 * time.Hour,
+}
+
+// GetExerciseCandles handles GET /api/v1/exercises/:id/candles
+func GetExerciseCandles(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	var exercise models.Exercise
+	if err := database.DB.Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	interval := c.DefaultQuery("interval", services.CandleIntervalDay)
+	defaultRange, ok := defaultCandleRanges[interval]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCandleInterval.Error()})
+		return
+	}
+
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format. Use YYYY-MM-DD"})
+			return
+		}
+		// Include the whole of the end day
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	from := to.Add(-defaultRange)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format. Use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from date must not be after to date"})
+		return
+	}
+
+	candles, err := services.GetCandles(exercise.ID, interval, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build candles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exercise_id": exercise.ID,
+		"ticker":      exercise.Ticker,
+		"interval":    interval,
+		"candles":     candles,
+		"count":       len(candles),
+	})
+}
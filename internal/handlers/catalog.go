@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCatalog handles GET /api/v1/catalog
+func GetCatalog(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	exercises, err := services.SearchCatalog(c.Query("q"), c.Query("category"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search catalog"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exercises": exercises,
+		"count":     len(exercises),
+	})
+}
+
+// GetCatalogExercise handles GET /api/v1/catalog/:id
+func GetCatalogExercise(c *gin.Context) {
+	catalogID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid catalog ID"})
+		return
+	}
+
+	exercise, err := services.GetCatalogExercise(uint(catalogID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Catalog exercise not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exercise": exercise})
+}
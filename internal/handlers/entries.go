@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"fitness-market/internal/database"
@@ -9,32 +12,83 @@ import (
 	"fitness-market/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// CreateEntryRequest logs a workout entry, either set by set in workout_sets or as sets
+// identical sets of weight x reps. Which of weight, reps, duration (seconds) and distance
+// (metres) are required depends on the exercise's measurement type. rpe or rir rates the
+// effort of every set that doesn't rate its own.
 type CreateEntryRequest struct {
-	ExerciseID uint    `json:"exercise_id" binding:"required"`
-	Weight     float64 `json:"weight" binding:"required"`
-	Reps       int     `json:"reps" binding:"required"`
-	Sets       int     `json:"sets" binding:"required"`
-	Notes      string  `json:"notes"`
-	Date       string  `json:"date"`
+	ExerciseID  uint                `json:"exercise_id" binding:"required"`
+	SessionID   *uint               `json:"session_id"`
+	Weight      float64             `json:"weight"`
+	Reps        int                 `json:"reps"`
+	Sets        int                 `json:"sets"`
+	Duration    int                 `json:"duration"`
+	Distance    float64             `json:"distance"`
+	WorkoutSets []WorkoutSetRequest `json:"workout_sets"`
+	RPE         *float64            `json:"rpe"`
+	RIR         *int                `json:"rir"`
+	Notes       string              `json:"notes"`
+	Date        string              `json:"date"`
+}
+
+// WorkoutSetRequest is one set of an entry; type defaults to working
+type WorkoutSetRequest struct {
+	Type     string   `json:"type"`
+	Weight   float64  `json:"weight"`
+	Reps     int      `json:"reps"`
+	Duration int      `json:"duration"`
+	Distance float64  `json:"distance"`
+	RPE      *float64 `json:"rpe"`
+	RIR      *int     `json:"rir"`
+}
+
+// UpdateEntryRequest changes the fields it sets. workout_sets replaces the entry's sets;
+// weight, reps, sets, duration or distance can instead be changed on an entry of identical
+// working sets, which are rebuilt with the new values.
+type UpdateEntryRequest struct {
+	SessionID   *uint               `json:"session_id"`
+	Weight      *float64            `json:"weight"`
+	Reps        *int                `json:"reps"`
+	Sets        *int                `json:"sets"`
+	Duration    *int                `json:"duration"`
+	Distance    *float64            `json:"distance"`
+	WorkoutSets []WorkoutSetRequest `json:"workout_sets"`
+	RPE         *float64            `json:"rpe"`
+	RIR         *int                `json:"rir"`
+	Notes       *string             `json:"notes"`
+	Date        *string             `json:"date"`
 }
 
 type EntryResponse struct {
-	ID              uint      `json:"id"`
-	UserID          uint      `json:"user_id"`
-	ExerciseID      uint      `json:"exercise_id"`
-	Weight          float64   `json:"weight"`
-	Reps            int       `json:"reps"`
-	Sets            int       `json:"sets"`
-	Notes           string    `json:"notes"`
-	Date            time.Time `json:"date"`
-	Score           float64   `json:"score"`
-	IsPR            bool      `json:"is_pr"`
-	CelebrationText string    `json:"celebration_text,omitempty"`
-	PreviousBest    float64   `json:"previous_best,omitempty"`
-	Improvement     float64   `json:"improvement,omitempty"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              uint                `json:"id"`
+	UserID          uint                `json:"user_id"`
+	ExerciseID      uint                `json:"exercise_id"`
+	SessionID       *uint               `json:"session_id"`
+	Weight          float64             `json:"weight"`
+	Reps            int                 `json:"reps"`
+	Sets            int                 `json:"sets"`
+	Duration        int                 `json:"duration"`
+	Distance        float64             `json:"distance"`
+	RPE             *float64            `json:"rpe"`
+	RIR             *int                `json:"rir"`
+	Notes           string              `json:"notes"`
+	Date            time.Time           `json:"date"`
+	Score           float64             `json:"score"`
+	ScoringStrategy string              `json:"scoring_strategy"`
+	ScoringVersion  int                 `json:"scoring_version"`
+	IsPR            bool                `json:"is_pr"`
+	StockPrice      float64             `json:"stock_price"`
+	CelebrationText string              `json:"celebration_text,omitempty"`
+	PreviousBest    float64             `json:"previous_best,omitempty"`
+	Improvement     float64             `json:"improvement,omitempty"`
+	Records         []services.PRRecord `json:"records"`
+	// DisplacedRecords are later records a backdated entry beat first
+	DisplacedRecords []services.DisplacedRecord `json:"displaced_records,omitempty"`
+	WorkoutSets      []models.WorkoutSet        `json:"workout_sets"`
+	CreatedAt        time.Time                  `json:"created_at"`
 }
 
 // CreateEntry handles POST /api/v1/entries
@@ -60,8 +114,18 @@ func CreateEntry(c *gin.Context) {
 		return
 	}
 
-	// Parse date or use current time
+	// Entries logged in a session default to the session's start
 	entryDate := time.Now()
+	if req.SessionID != nil {
+		var session models.WorkoutSession
+		if err := db.Where("id = ? AND user_id = ?", *req.SessionID, userID).First(&session).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		entryDate = session.StartedAt
+	}
+
+	// Parse date or use current time
 	if req.Date != "" {
 		parsedDate, err := time.Parse("2006-01-02", req.Date)
 		if err != nil {
@@ -71,56 +135,428 @@ func CreateEntry(c *gin.Context) {
 		entryDate = parsedDate
 	}
 
-	// Detect if this is a PR
-	prResult, err := services.DetectPR(userID.(uint), req.ExerciseID, req.Weight, req.Reps, req.Sets)
+	// Check the entry records what the exercise measures
+	measurementType := services.MeasurementType(exercise)
+	sets, err := entrySetsFromRequest(measurementType, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check PR status"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ValidateSets(measurementType, sets); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ValidateEffort(req.RPE, req.RIR); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	summary := services.SummarizeSets(measurementType, sets)
+
+	// Score the entry's working sets with the exercise's strategy
+	score, err := services.ScoreEntry(exercise, sets, entryDate, req.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to score entry"})
 		return
 	}
 
-	// Create the workout entry
 	entry := models.WorkoutEntry{
-		UserID:     userID.(uint),
-		ExerciseID: req.ExerciseID,
-		Weight:     req.Weight,
-		Reps:       req.Reps,
-		Sets:       req.Sets,
-		Notes:      req.Notes,
-		Date:       entryDate,
-		Score:      prResult.Score,
-		IsPR:       prResult.IsPR,
+		UserID:          userID.(uint),
+		ExerciseID:      req.ExerciseID,
+		SessionID:       req.SessionID,
+		Weight:          summary.Weight,
+		Reps:            summary.Reps,
+		Sets:            summary.Sets,
+		Duration:        summary.Duration,
+		Distance:        summary.Distance,
+		RPE:             req.RPE,
+		RIR:             req.RIR,
+		Notes:           req.Notes,
+		Date:            entryDate,
+		Score:           score.Value,
+		ScoringStrategy: score.Strategy,
+		ScoringVersion:  score.Version,
+		WorkoutSets:     sets,
+	}
+
+	// A backdated entry is judged against the records as they stood on its date, which
+	// means replaying the exercise's timeline once it is saved
+	backdated, err := services.IsBackdated(entry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check PR status"})
+		return
+	}
+
+	// Detect every record this entry breaks
+	var records []services.PRRecord
+	if !backdated {
+		records, err = services.DetectPRs(entry, measurementType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check PR status"})
+			return
+		}
+		entry.IsPR = len(services.BrokenRecords(records)) > 0
 	}
 
+	// Create the workout entry along with its sets
 	if err := db.Create(&entry).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create entry"})
 		return
 	}
 
-	// If it's a PR, record it in PR history
-	if prResult.IsPR {
-		if err := services.RecordPR(userID.(uint), req.ExerciseID, entry.ID, req.Weight, req.Reps, req.Sets, entryDate); err != nil {
-			// Log error but don't fail the request
-			// The entry was created successfully
+	// Record any PRs in PR history
+	var displaced []services.DisplacedRecord
+	if backdated {
+		records, displaced, err = services.ReplayPRHistory(entry)
+		if err != nil {
+			// Log error but don't fail the request, the entry was created successfully
+			log.Printf("Failed to replay PR history for exercise %d: %v", exercise.ID, err)
+		}
+		entry.IsPR = len(records) > 0
+	} else {
+		if err := services.RecordPRs(entry, records); err != nil {
+			// Log error but don't fail the request, the entry was created successfully
+			log.Printf("Failed to record PRs for entry %d: %v", entry.ID, err)
 		}
+		// First values are kept as baselines but only beaten records are celebrated
+		records = services.BrokenRecords(records)
+	}
+
+	// Reprice the exercise now that it has a new score
+	stockPrice, err := services.RepriceExercise(exercise.ID, services.PriceReasonEntryCreated)
+	if err != nil {
+		log.Printf("Failed to reprice exercise %d: %v", exercise.ID, err)
+		stockPrice = exercise.StockPrice
 	}
 
 	// Build response with celebration indicator
 	response := EntryResponse{
-		ID:              entry.ID,
-		UserID:          entry.UserID,
-		ExerciseID:      entry.ExerciseID,
-		Weight:          entry.Weight,
-		Reps:            entry.Reps,
-		Sets:            entry.Sets,
-		Notes:           entry.Notes,
-		Date:            entry.Date,
-		Score:           entry.Score,
-		IsPR:            entry.IsPR,
-		CelebrationText: prResult.CelebrationText,
-		PreviousBest:    prResult.PreviousBest,
-		Improvement:     prResult.Improvement,
-		CreatedAt:       entry.CreatedAt,
+		ID:               entry.ID,
+		UserID:           entry.UserID,
+		ExerciseID:       entry.ExerciseID,
+		SessionID:        entry.SessionID,
+		Weight:           entry.Weight,
+		Reps:             entry.Reps,
+		Sets:             entry.Sets,
+		Duration:         entry.Duration,
+		Distance:         entry.Distance,
+		RPE:              entry.RPE,
+		RIR:              entry.RIR,
+		Notes:            entry.Notes,
+		Date:             entry.Date,
+		Score:            entry.Score,
+		ScoringStrategy:  entry.ScoringStrategy,
+		ScoringVersion:   entry.ScoringVersion,
+		IsPR:             entry.IsPR,
+		StockPrice:       stockPrice,
+		Records:          records,
+		DisplacedRecords: displaced,
+		WorkoutSets:      entry.WorkoutSets,
+		CreatedAt:        entry.CreatedAt,
+	}
+
+	// The top-level celebration describes the headline record: the score PR if there is one
+	if len(records) > 0 {
+		response.CelebrationText = records[0].CelebrationText
+		response.PreviousBest = records[0].PreviousBest
+		response.Improvement = records[0].Improvement
 	}
 
 	c.JSON(http.StatusCreated, response)
 }
+
+// ListEntries handles GET /api/v1/entries
+func ListEntries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	filter := services.EntryFilter{Category: c.Query("category")}
+	if value := c.Query("exercise_id"); value != "" {
+		exerciseID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+			return
+		}
+		filter.ExerciseID = uint(exerciseID)
+	}
+	if value := c.Query("session_id"); value != "" {
+		sessionID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+			return
+		}
+		filter.SessionID = uint(sessionID)
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+		filter.From = from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+		// to is inclusive of the whole day
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if value := c.Query("is_pr"); value != "" {
+		isPR, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "is_pr must be true or false"})
+			return
+		}
+		filter.IsPR = &isPR
+	}
+
+	entries, next, err := services.ListEntries(userID.(uint), filter, c.Query("sort"), c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEntrySort) || errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":     entries,
+		"count":       len(entries),
+		"next_cursor": next,
+	})
+}
+
+// GetEntry handles GET /api/v1/entries/:id
+func GetEntry(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		return
+	}
+
+	entry, err := services.GetEntry(userID.(uint), uint(entryID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry"})
+		return
+	}
+
+	records, err := services.GetEntryRecords(entry.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry records"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entry":   entry,
+		"records": records,
+	})
+}
+
+// UpdateEntry handles PUT /api/v1/entries/:id
+func UpdateEntry(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		return
+	}
+
+	entry, err := services.GetEntry(userID.(uint), uint(entryID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry"})
+		return
+	}
+
+	var req UpdateEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.SessionID != nil {
+		var session models.WorkoutSession
+		if err := database.GetDB().Where("id = ? AND user_id = ?", *req.SessionID, userID).First(&session).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		entry.SessionID = req.SessionID
+	}
+	if req.Date != nil {
+		date, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		entry.Date = date
+	}
+	if req.Notes != nil {
+		entry.Notes = *req.Notes
+	}
+	if req.RPE != nil || req.RIR != nil {
+		if err := services.ValidateEffort(req.RPE, req.RIR); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		entry.RPE, entry.RIR = req.RPE, req.RIR
+	}
+
+	// Work out the entry's new sets, if they change
+	measurementType := services.MeasurementType(entry.Exercise)
+	performanceChanged := req.Weight != nil || req.Reps != nil || req.Sets != nil || req.Duration != nil || req.Distance != nil
+	var sets []models.WorkoutSet
+	switch {
+	case len(req.WorkoutSets) > 0 && performanceChanged:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "give either workout_sets or weight, reps and sets, not both"})
+		return
+	case len(req.WorkoutSets) > 0:
+		sets = workoutSetsFromRequest(req.WorkoutSets)
+	case performanceChanged && !services.UniformSets(*entry):
+		// Rewriting varied sets from the top set would lose warmups, drop sets and per-set effort
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrNonUniformSets.Error()})
+		return
+	case performanceChanged:
+		performance := services.EntryPerformance(*entry)
+		if req.Weight != nil {
+			performance.Weight = *req.Weight
+		}
+		if req.Reps != nil {
+			performance.Reps = *req.Reps
+		}
+		if req.Sets != nil {
+			performance.Sets = *req.Sets
+		}
+		if req.Duration != nil {
+			performance.Duration = *req.Duration
+		}
+		if req.Distance != nil {
+			performance.Distance = *req.Distance
+		}
+		performance = services.NormalizePerformance(measurementType, performance)
+		if err := services.ValidatePerformance(measurementType, performance); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sets = services.ExpandSets(performance)
+	}
+	if sets != nil {
+		if err := services.ValidateSets(measurementType, sets); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Rescores the entry, rebuilds the exercise's records and reprices it
+	if err := services.UpdateEntry(entry, sets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry"})
+		return
+	}
+
+	records, err := services.GetEntryRecords(entry.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry records"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Entry updated successfully",
+		"entry":   entry,
+		"records": records,
+	})
+}
+
+// DeleteEntry handles DELETE /api/v1/entries/:id
+func DeleteEntry(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		return
+	}
+
+	if err := services.DeleteEntry(userID.(uint), uint(entryID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted successfully"})
+}
+
+// entrySetsFromRequest returns the sets an entry request logs, expanding weight x reps x sets
+// into identical sets when workout_sets isn't given
+func entrySetsFromRequest(measurementType string, req CreateEntryRequest) ([]models.WorkoutSet, error) {
+	if len(req.WorkoutSets) == 0 {
+		performance := services.NormalizePerformance(measurementType, services.Performance{
+			Weight:   req.Weight,
+			Reps:     req.Reps,
+			Sets:     req.Sets,
+			Duration: req.Duration,
+			Distance: req.Distance,
+		})
+		if err := services.ValidatePerformance(measurementType, performance); err != nil {
+			return nil, err
+		}
+		return services.ExpandSets(performance), nil
+	}
+
+	if req.Weight != 0 || req.Reps != 0 || req.Sets != 0 || req.Duration != 0 || req.Distance != 0 {
+		return nil, errors.New("give either workout_sets or weight, reps and sets, not both")
+	}
+
+	return workoutSetsFromRequest(req.WorkoutSets), nil
+}
+
+func workoutSetsFromRequest(requests []WorkoutSetRequest) []models.WorkoutSet {
+	sets := make([]models.WorkoutSet, 0, len(requests))
+	for _, set := range requests {
+		sets = append(sets, models.WorkoutSet{
+			Type:     set.Type,
+			Weight:   set.Weight,
+			Reps:     set.Reps,
+			Duration: set.Duration,
+			Distance: set.Distance,
+			RPE:      set.RPE,
+			RIR:      set.RIR,
+		})
+	}
+	return sets
+}
@@ -3,44 +3,54 @@ package handlers
 import (
 	"errors"
 	"net/http"
-	"regexp"
 	"strconv"
 	"strings"
 
 	"fitness-market/internal/database"
 	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+	"fitness-market/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// CreateExerciseRequest creates a custom exercise, or one linked to the catalog when
+// catalog_id is set. Fields left empty are filled in from the catalog entry.
 type CreateExerciseRequest struct {
-	Ticker      string  `json:"ticker" binding:"required"`
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Category    string  `json:"category" binding:"required"`
-	StockPrice  float64 `json:"stock_price"`
+	CatalogID   *uint  `json:"catalog_id"`
+	Ticker      string `json:"ticker"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	// ScoringStrategy overrides the user's default strategy for this exercise
+	ScoringStrategy string `json:"scoring_strategy"`
+	// MeasurementType is what entries record; defaults to weight_reps
+	MeasurementType string `json:"measurement_type"`
 }
 
 type UpdateExerciseRequest struct {
-	Ticker      string  `json:"ticker"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Category    string  `json:"category"`
-	StockPrice  float64 `json:"stock_price"`
+	Ticker      string `json:"ticker"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	// CatalogExerciseID links the exercise to a catalog entry, or unlinks it when 0
+	CatalogExerciseID *uint `json:"catalog_exercise_id"`
+	// ScoringStrategy set to "" makes the exercise follow the user's default again
+	ScoringStrategy *string `json:"scoring_strategy"`
+	// MeasurementType can only change while the exercise has no entries
+	MeasurementType string `json:"measurement_type"`
 }
 
-// validateTicker validates the ticker symbol format
-func validateTicker(ticker string) error {
-	if len(ticker) < 2 || len(ticker) > 10 {
-		return errors.New("ticker symbol must be between 2 and 10 characters")
-	}
-	// Ticker must be uppercase alphanumeric only
-	matched, _ := regexp.MatchString("^[A-Z0-9]+$", ticker)
-	if !matched {
-		return errors.New("ticker symbol must contain only uppercase letters and numbers")
-	}
-	return nil
+// OverridePriceRequest manually sets an exercise's stock price
+type OverridePriceRequest struct {
+	StockPrice float64 `json:"stock_price" binding:"required,gt=0"`
+}
+
+// SplitRequest splits (ratio > 1) or reverse splits (ratio < 1) an exercise's stock, by
+// at most 100-for-1 either way
+type SplitRequest struct {
+	Ratio float64 `json:"ratio" binding:"required,gte=0.01,lte=100"`
 }
 
 // CreateExercise creates a new exercise for the authenticated user
@@ -57,12 +67,63 @@ func CreateExercise(c *gin.Context) {
 		return
 	}
 
+	// Fill in defaults from the catalog entry
+	var entry *models.CatalogExercise
+	if req.CatalogID != nil {
+		var err error
+		entry, err = services.GetCatalogExercise(*req.CatalogID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Catalog exercise not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if strings.TrimSpace(req.Ticker) == "" {
+			req.Ticker = entry.Ticker
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			req.Name = entry.Name
+		}
+		if req.Description == "" {
+			req.Description = entry.Description
+		}
+		if strings.TrimSpace(req.Category) == "" {
+			req.Category = entry.Category
+		}
+		if req.MeasurementType == "" {
+			req.MeasurementType = entry.MeasurementType
+		}
+	}
+
 	// Normalize ticker to uppercase
 	req.Ticker = strings.ToUpper(strings.TrimSpace(req.Ticker))
 	req.Name = strings.TrimSpace(req.Name)
+	req.Category = strings.TrimSpace(req.Category)
+
+	if req.Name == "" || req.Category == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and category are required unless catalog_id is given"})
+		return
+	}
+
+	if req.ScoringStrategy != "" {
+		if _, err := scoring.Get(req.ScoringStrategy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scoring strategy"})
+			return
+		}
+	}
+
+	if req.MeasurementType == "" {
+		req.MeasurementType = services.MeasurementWeightReps
+	}
+	if err := services.ValidateMeasurementType(req.MeasurementType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Validate ticker format
-	if err := validateTicker(req.Ticker); err != nil {
+	if err := services.ValidateTicker(req.Ticker); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,12 +141,16 @@ func CreateExercise(c *gin.Context) {
 	}
 
 	exercise := models.Exercise{
-		UserID:      userID.(uint),
-		Ticker:      req.Ticker,
-		Name:        req.Name,
-		Description: req.Description,
-		Category:    req.Category,
-		StockPrice:  req.StockPrice,
+		UserID:            userID.(uint),
+		Ticker:            req.Ticker,
+		Name:              req.Name,
+		Description:       req.Description,
+		Category:          req.Category,
+		StockPrice:        services.InitialStockPrice,
+		CatalogExerciseID: req.CatalogID,
+		CatalogExercise:   entry,
+		ScoringStrategy:   req.ScoringStrategy,
+		MeasurementType:   req.MeasurementType,
 	}
 
 	if err := database.DB.Create(&exercise).Error; err != nil {
@@ -112,7 +177,7 @@ func GetExercises(c *gin.Context) {
 	}
 
 	var exercises []models.Exercise
-	if err := database.DB.Where("user_id = ?", userID).Find(&exercises).Error; err != nil {
+	if err := database.DB.Where("user_id = ?", userID).Preload("CatalogExercise").Find(&exercises).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exercises"})
 		return
 	}
@@ -153,7 +218,7 @@ func UpdateExercise(c *gin.Context) {
 	// Update fields if provided
 	if req.Ticker != "" {
 		req.Ticker = strings.ToUpper(strings.TrimSpace(req.Ticker))
-		if err := validateTicker(req.Ticker); err != nil {
+		if err := services.ValidateTicker(req.Ticker); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -179,10 +244,49 @@ func UpdateExercise(c *gin.Context) {
 	if req.Category != "" {
 		exercise.Category = req.Category
 	}
-	if req.StockPrice != 0 {
-		exercise.StockPrice = req.StockPrice
+	if req.CatalogExerciseID != nil {
+		exercise.CatalogExerciseID = nil
+		if *req.CatalogExerciseID != 0 {
+			entry, err := services.GetCatalogExercise(*req.CatalogExerciseID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Catalog exercise not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			exercise.CatalogExerciseID = &entry.ID
+		}
+	}
+	rescore := false
+	if req.ScoringStrategy != nil && *req.ScoringStrategy != exercise.ScoringStrategy {
+		if *req.ScoringStrategy != "" {
+			if _, err := scoring.Get(*req.ScoringStrategy); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scoring strategy"})
+				return
+			}
+		}
+		exercise.ScoringStrategy = *req.ScoringStrategy
+		rescore = true
+	}
+	if req.MeasurementType != "" && req.MeasurementType != services.MeasurementType(exercise) {
+		if err := services.ValidateMeasurementType(req.MeasurementType); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Existing entries were recorded in the old type's fields
+		var entryCount int64
+		if err := database.DB.Model(&models.WorkoutEntry{}).Where("exercise_id = ?", exercise.ID).Count(&entryCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if entryCount > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Measurement type cannot be changed once an exercise has entries"})
+			return
+		}
+		exercise.MeasurementType = req.MeasurementType
 	}
-
 	if err := database.DB.Save(&exercise).Error; err != nil {
 		if strings.Contains(err.Error(), "ticker symbol already exists") {
 			c.JSON(http.StatusConflict, gin.H{"error": "Ticker symbol already exists for this user"})
@@ -192,6 +296,25 @@ func UpdateExercise(c *gin.Context) {
 		return
 	}
 
+	// Existing entries were scored with the old strategy and are rescored in the background
+	var job *models.RescoreJob
+	if rescore {
+		job, err = services.StartRescoreJob(exercise.UserID, services.RescoreReasonStrategy, []uint{exercise.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rescore exercise entries"})
+			return
+		}
+	}
+	database.DB.Preload("CatalogExercise").First(&exercise, exercise.ID)
+
+	if job != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":     "Exercise updated successfully",
+			"exercise":    exercise,
+			"rescore_job": job,
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Exercise updated successfully",
 		"exercise": exercise,
@@ -229,3 +352,193 @@ func DeleteExercise(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Exercise deleted successfully"})
 }
+
+// OverrideExercisePrice manually sets an exercise's stock price, outside of the pricing engine
+func OverrideExercisePrice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	var exercise models.Exercise
+	if err := database.DB.Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var req OverridePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := services.OverrideStockPrice(&exercise, req.StockPrice)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to override stock price"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Stock price overridden successfully",
+		"exercise":     exercise,
+		"price_change": change,
+	})
+}
+
+// GetExercisePriceHistory returns the recorded price changes for an exercise
+func GetExercisePriceHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	adjusted := c.DefaultQuery("adjusted", "true") != "false"
+
+	history, err := services.GetPriceHistory(userID.(uint), uint(exerciseID), adjusted)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exercise_id":   exerciseID,
+		"adjusted":      adjusted,
+		"price_history": history,
+		"count":         len(history),
+	})
+}
+
+// SplitExercise manually splits or reverse splits an exercise's stock
+func SplitExercise(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	var exercise models.Exercise
+	if err := database.DB.Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var req SplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action, err := services.ApplySplit(&exercise, req.Ratio, services.CorporateActionTriggerManual)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSplitRatio) || errors.Is(err, services.ErrSplitBelowMinPrice) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split exercise"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":          "Split applied successfully",
+		"exercise":         exercise,
+		"corporate_action": action,
+	})
+}
+
+// GetExerciseCorporateActions returns the splits and reverse splits of an exercise
+func GetExerciseCorporateActions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	actions, err := services.GetCorporateActions(userID.(uint), uint(exerciseID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch corporate actions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exercise_id":       exerciseID,
+		"corporate_actions": actions,
+		"count":             len(actions),
+	})
+}
+
+// GetExerciseSuggestion recommends the load for the next set from the exercise's history
+func GetExerciseSuggestion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	targetReps, err := strconv.Atoi(c.DefaultQuery("target_reps", "5"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidSuggestion.Error()})
+		return
+	}
+	targetRPE, err := strconv.ParseFloat(c.DefaultQuery("target_rpe", "8"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidSuggestion.Error()})
+		return
+	}
+
+	suggestion, err := services.SuggestLoad(userID.(uint), uint(exerciseID), targetReps, targetRPE)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+		case errors.Is(err, services.ErrInvalidSuggestion), errors.Is(err, services.ErrSuggestionUnsupported):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNoSuggestionHistory):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute suggestion"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestion": suggestion})
+}
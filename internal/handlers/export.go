@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportData handles GET /api/v1/export. It streams a ZIP of all of the user's data with
+// each table as JSON or, with format=csv, as CSV.
+func ExportData(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", services.ExportFormatJSON))
+	if format != services.ExportFormatJSON && format != services.ExportFormatCSV {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidExportFormat.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("fitness-market-export-%s.zip", time.Now().Format("2006-01-02"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// The archive is streamed, so a failure part way through can only cut it short
+	if err := services.WriteExport(c.Writer, userID.(uint), format); err != nil {
+		log.Printf("Failed to export data for user %v: %v", userID, err)
+	}
+}
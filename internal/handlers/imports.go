@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitness-market/internal/importer"
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateImport handles POST /api/v1/imports. It takes a multipart upload with a CSV, or a
+// ZIP from GET /export to restore, in file and optional format (strong, hevy, fitnotes or
+// generic; detected if empty), units (metric or imperial, for exports that don't say) and
+// dry_run fields. A dry run responds with the finished preview; a real import responds 202
+// with the job to poll.
+func CreateImport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxImportSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV or export archive is required in the file field"})
+		return
+	}
+	if header.Size > services.MaxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d MB", services.MaxImportSize>>20)})
+		return
+	}
+
+	dryRun := false
+	if value := c.PostForm("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	opts := importer.Options{
+		Format: strings.ToLower(strings.TrimSpace(c.PostForm("format"))),
+		Units:  strings.ToLower(strings.TrimSpace(c.PostForm("units"))),
+	}
+
+	job, err := services.CreateImport(userID.(uint), header.Filename, data, opts, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, importer.ErrEmptyFile),
+			errors.Is(err, importer.ErrUnknownFormat),
+			errors.Is(err, importer.ErrInvalidFormat),
+			errors.Is(err, importer.ErrInvalidUnits),
+			errors.Is(err, importer.ErrMissingColumns),
+			errors.Is(err, importer.ErrUnreadableFile),
+			errors.Is(err, services.ErrInvalidArchive),
+			errors.Is(err, services.ErrUnsupportedArchive):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import file"})
+		}
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"import": job})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"import": job})
+}
+
+// GetImports handles GET /api/v1/imports
+func GetImports(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	jobs, err := services.GetImports(userID.(uint), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch imports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imports": jobs,
+		"count":   len(jobs),
+	})
+}
+
+// GetImport handles GET /api/v1/imports/:id
+func GetImport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	job, err := services.GetImport(userID.(uint), uint(jobID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"import": job})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetIndices handles GET /api/v1/indices
+func GetIndices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	indices, err := services.GetCurrentIndices(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute indices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"indices": indices,
+		"count":   len(indices),
+	})
+}
+
+// GetIndexHistory handles GET /api/v1/indices/:symbol/history
+func GetIndexHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	symbol := strings.ToUpper(c.Param("symbol"))
+	rangeKey := c.DefaultQuery("range", "1m")
+
+	history, err := services.GetIndexHistory(userID.(uint), symbol, rangeKey)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidHistoryRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch index history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":  symbol,
+		"range":   rangeKey,
+		"history": history,
+		"count":   len(history),
+	})
+}
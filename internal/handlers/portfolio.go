@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPortfolio handles GET /api/v1/portfolio
+func GetPortfolio(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	overview, err := services.GetPortfolioOverview(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch portfolio"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetPortfolioHistory handles GET /api/v1/portfolio/history
+func GetPortfolioHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rangeKey := c.DefaultQuery("range", "1m")
+	history, err := services.GetPortfolioHistory(userID.(uint), rangeKey)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidHistoryRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch portfolio history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"range":   rangeKey,
+		"history": history,
+		"count":   len(history),
+	})
+}
+
+// GetPortfolioDividends handles GET /api/v1/portfolio/dividends
+func GetPortfolioDividends(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	dividends, err := services.GetDividends(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dividends"})
+		return
+	}
+
+	total, err := services.GetDividendIncome(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dividends"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dividends":    dividends,
+		"total_income": total,
+		"count":        len(dividends),
+	})
+}
+
+// GetPortfolioCash handles GET /api/v1/portfolio/cash
+func GetPortfolioCash(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	balance, err := services.GetCashBalance(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cash balance"})
+		return
+	}
+
+	ledger, err := services.GetCashLedger(userID.(uint), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cash ledger"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance": balance,
+		"ledger":  ledger,
+		"count":   len(ledger),
+	})
+}
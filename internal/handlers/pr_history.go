@@ -24,7 +24,7 @@ func GetPRHistoryByExercise(c *gin.Context) {
 		return
 	}
 
-	history, err := services.GetPRHistory(userID.(uint), uint(exerciseID))
+	history, err := services.GetPRHistory(userID.(uint), uint(exerciseID), c.Query("type"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch PR history"})
 		return
@@ -37,7 +37,7 @@ func GetPRHistoryByExercise(c *gin.Context) {
 	})
 }
 
-// GetAllUserPRs returns the current best PR for each exercise
+// GetAllUserPRs returns the current best PR of each type for each exercise
 func GetAllUserPRs(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
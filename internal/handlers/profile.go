@@ -3,6 +3,9 @@ package handlers
 import (
 	"fitness-market/internal/database"
 	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+	"fitness-market/internal/services"
+	"log"
 	"net/http"
 	"time"
 
@@ -15,6 +18,14 @@ type UpdateBodyweightRequest struct {
 	RecordedAt time.Time `json:"recorded_at"`
 }
 
+type UpdateProfileRequest struct {
+	Timezone        string  `json:"timezone"`
+	Sex             *string `json:"sex"`
+	ScoringStrategy string  `json:"scoring_strategy"`
+	// PlateIncrement is the smallest load jump suggestions are rounded to
+	PlateIncrement *float64 `json:"plate_increment" binding:"omitempty,gt=0"`
+}
+
 type AddExercisePRRequest struct {
 	ExerciseName string    `json:"exercise_name" binding:"required"`
 	Weight       float64   `json:"weight" binding:"required,gt=0"`
@@ -29,7 +40,7 @@ func GetUserProfile(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	var profile models.UserProfile
 	if err := database.DB.Where("user_id = ?", user.ID).First(&profile).Error; err != nil {
@@ -44,19 +55,90 @@ func GetUserProfile(c *gin.Context) {
 	database.DB.Where("user_id = ?", user.ID).Find(&exercisePRs)
 
 	c.JSON(http.StatusOK, gin.H{
-		"profile":          profile,
+		"profile":            profile,
 		"current_bodyweight": latestBodyweight,
-		"exercise_prs":     exercisePRs,
+		"exercise_prs":       exercisePRs,
 	})
 }
 
+// UpdateUserProfile updates the authenticated user's profile settings
+func UpdateUserProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var profile models.UserProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		profile = models.UserProfile{UserID: userID.(uint)}
+	}
+
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+			return
+		}
+		profile.Timezone = req.Timezone
+	}
+
+	// Changing either of these changes what existing scores mean
+	rescore := false
+	if req.Sex != nil && *req.Sex != profile.Sex {
+		if *req.Sex != "" && *req.Sex != scoring.SexMale && *req.Sex != scoring.SexFemale {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sex must be male, female or empty"})
+			return
+		}
+		profile.Sex = *req.Sex
+		rescore = true
+	}
+	if req.ScoringStrategy != "" && req.ScoringStrategy != profile.ScoringStrategy {
+		if _, err := scoring.Get(req.ScoringStrategy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scoring strategy"})
+			return
+		}
+		profile.ScoringStrategy = req.ScoringStrategy
+		rescore = true
+	}
+
+	if req.PlateIncrement != nil {
+		profile.PlateIncrement = *req.PlateIncrement
+	}
+
+	if err := database.DB.Save(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	// Entries are rescored in the background; the response is 202 with the job to poll
+	if rescore {
+		job, err := services.StartUserRescore(profile.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rescore entries"})
+			return
+		}
+		if job != nil {
+			c.JSON(http.StatusAccepted, gin.H{"profile": profile, "rescore_job": job})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
 func AddBodyweight(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	var req UpdateBodyweightRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -86,7 +168,89 @@ func AddBodyweight(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"bodyweight": entry})
+	respondBodyweightChange(c, http.StatusCreated, gin.H{"bodyweight": entry}, user.ID, entry.ID, entry.RecordedAt)
+}
+
+// UpdateBodyweight handles PUT /api/v1/profile/bodyweight/:id
+func UpdateBodyweight(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	user := userInterface.(*models.User)
+
+	var entry models.BodyweightEntry
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bodyweight entry not found"})
+		return
+	}
+
+	var req UpdateBodyweightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previousRecordedAt := entry.RecordedAt
+	entry.Weight = req.Weight
+	if req.Unit != "" {
+		entry.Unit = req.Unit
+	}
+	if !req.RecordedAt.IsZero() {
+		entry.RecordedAt = req.RecordedAt
+	}
+
+	if err := database.DB.Save(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bodyweight"})
+		return
+	}
+
+	// Entries around both the old and the new date were scored against this entry
+	rescoreAt := []time.Time{entry.RecordedAt}
+	if !previousRecordedAt.Equal(entry.RecordedAt) {
+		rescoreAt = append(rescoreAt, previousRecordedAt)
+	}
+	respondBodyweightChange(c, http.StatusOK, gin.H{"bodyweight": entry}, user.ID, entry.ID, rescoreAt...)
+}
+
+// DeleteBodyweight handles DELETE /api/v1/profile/bodyweight/:id
+func DeleteBodyweight(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	user := userInterface.(*models.User)
+
+	var entry models.BodyweightEntry
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bodyweight entry not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bodyweight"})
+		return
+	}
+
+	respondBodyweightChange(c, http.StatusOK, gin.H{"message": "Bodyweight entry deleted"}, user.ID, entry.ID, entry.RecordedAt)
+}
+
+// respondBodyweightChange starts rescoring the workout entries whose interpolated bodyweight
+// depends on a changed bodyweight entry at the given times, then responds with body. While
+// entries are rescored in the background the response is 202 with the rescore job to poll.
+// A rescore that fails to start is logged; the bodyweight change stands.
+func respondBodyweightChange(c *gin.Context, status int, body gin.H, userID uint, entryID uint, times ...time.Time) {
+	job, err := services.StartBodyweightRescore(userID, entryID, times...)
+	if err != nil {
+		log.Printf("Failed to rescore entries after bodyweight change for user %d: %v", userID, err)
+	}
+	if job != nil {
+		status = http.StatusAccepted
+		body["rescore_job"] = job
+	}
+	c.JSON(status, body)
 }
 
 func GetBodyweightHistory(c *gin.Context) {
@@ -95,7 +259,7 @@ func GetBodyweightHistory(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	var entries []models.BodyweightEntry
 	database.DB.Where("user_id = ?", user.ID).Order("recorded_at desc").Find(&entries)
@@ -109,7 +273,7 @@ func AddExercisePR(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	var req AddExercisePRRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -155,7 +319,7 @@ func GetExercisePRs(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	var prs []models.ExercisePR
 	database.DB.Where("user_id = ?", user.ID).Order("exercise_name asc, recorded_at desc").Find(&prs)
@@ -169,7 +333,7 @@ func UpdateExercisePR(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	prID := c.Param("id")
 
@@ -211,7 +375,7 @@ func DeleteExercisePR(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
 	prID := c.Param("id")
 
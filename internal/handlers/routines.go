@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitness-market/internal/models"
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RoutineExerciseRequest is one exercise of a routine. Targets left out are filled in from
+// the exercise's latest entry when the routine is started; give target_weight (kg) or
+// target_percentage of the exercise's e1RM, not both.
+type RoutineExerciseRequest struct {
+	ExerciseID       uint     `json:"exercise_id" binding:"required"`
+	TargetSets       int      `json:"target_sets" binding:"gte=0,lte=20"`
+	TargetReps       int      `json:"target_reps" binding:"gte=0"`
+	TargetWeight     *float64 `json:"target_weight" binding:"omitempty,gte=0"`
+	TargetPercentage *float64 `json:"target_percentage" binding:"omitempty,gt=0,lte=100"`
+	Notes            string   `json:"notes"`
+}
+
+// CreateRoutineRequest creates a routine with its exercises in the order given
+type CreateRoutineRequest struct {
+	Name      string                   `json:"name" binding:"required"`
+	Notes     string                   `json:"notes"`
+	Exercises []RoutineExerciseRequest `json:"exercises" binding:"required,dive"`
+}
+
+// UpdateRoutineRequest changes the fields it sets; exercises replaces the routine's exercises
+type UpdateRoutineRequest struct {
+	Name      *string                  `json:"name"`
+	Notes     *string                  `json:"notes"`
+	Exercises []RoutineExerciseRequest `json:"exercises" binding:"omitempty,dive"`
+}
+
+// RoutineEntryDraft is an entry pre-filled from one exercise of a started routine. Entry
+// can be sent to POST /entries as is, or adjusted, once the sets are done. Warning says why
+// a routine target could not be applied.
+type RoutineEntryDraft struct {
+	RoutineExerciseID uint                 `json:"routine_exercise_id"`
+	Exercise          models.Exercise      `json:"exercise"`
+	Notes             string               `json:"notes"`
+	Entry             CreateEntryRequest   `json:"entry"`
+	Previous          *models.WorkoutEntry `json:"previous"`
+	Warning           string               `json:"warning,omitempty"`
+}
+
+// GetRoutines handles GET /api/v1/routines
+func GetRoutines(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	routines, err := services.GetRoutines(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch routines"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"routines": routines,
+		"count":    len(routines),
+	})
+}
+
+// CreateRoutine handles POST /api/v1/routines
+func CreateRoutine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateRoutineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	routine := models.Routine{
+		UserID:    userID.(uint),
+		Name:      name,
+		Notes:     req.Notes,
+		Exercises: routineExercisesFromRequest(req.Exercises),
+	}
+	if err := services.CreateRoutine(&routine); err != nil {
+		respondRoutineError(c, err, "Failed to create routine")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Routine created successfully",
+		"routine": routine,
+	})
+}
+
+// GetRoutine handles GET /api/v1/routines/:id
+func GetRoutine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	routineID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid routine ID"})
+		return
+	}
+
+	routine, err := services.GetRoutine(userID.(uint), uint(routineID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Routine not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"routine": routine})
+}
+
+// UpdateRoutine handles PUT /api/v1/routines/:id
+func UpdateRoutine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	routineID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid routine ID"})
+		return
+	}
+
+	routine, err := services.GetRoutine(userID.(uint), uint(routineID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Routine not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var req UpdateRoutineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+		routine.Name = name
+	}
+	if req.Notes != nil {
+		routine.Notes = *req.Notes
+	}
+
+	if err := services.UpdateRoutine(routine, routineExercisesFromRequest(req.Exercises)); err != nil {
+		respondRoutineError(c, err, "Failed to update routine")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Routine updated successfully",
+		"routine": routine,
+	})
+}
+
+// DeleteRoutine handles DELETE /api/v1/routines/:id
+func DeleteRoutine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	routineID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid routine ID"})
+		return
+	}
+
+	if err := services.DeleteRoutine(userID.(uint), uint(routineID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Routine not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete routine"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Routine deleted successfully"})
+}
+
+// StartRoutine handles POST /api/v1/routines/:id/start. It starts a session titled after
+// the routine, taking the same optional fields as POST /sessions, and responds with a
+// draft entry for each of the routine's exercises.
+func StartRoutine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	routineID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid routine ID"})
+		return
+	}
+
+	var req StartSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session := models.WorkoutSession{
+		Title:      strings.TrimSpace(req.Title),
+		Notes:      req.Notes,
+		Location:   strings.TrimSpace(req.Location),
+		Bodyweight: req.Bodyweight,
+	}
+	if req.StartedAt != nil {
+		session.StartedAt = *req.StartedAt
+	}
+
+	start, err := services.StartRoutine(userID.(uint), uint(routineID), &session)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Routine not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start routine"})
+		return
+	}
+
+	drafts := make([]RoutineEntryDraft, 0, len(start.Drafts))
+	for _, draft := range start.Drafts {
+		sessionID := start.Session.ID
+		drafts = append(drafts, RoutineEntryDraft{
+			RoutineExerciseID: draft.RoutineExercise.ID,
+			Exercise:          draft.RoutineExercise.Exercise,
+			Notes:             draft.RoutineExercise.Notes,
+			Entry: CreateEntryRequest{
+				ExerciseID:  draft.RoutineExercise.ExerciseID,
+				SessionID:   &sessionID,
+				WorkoutSets: workoutSetRequests(draft.Sets),
+			},
+			Previous: draft.Previous,
+			Warning:  draft.Warning,
+		})
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"session": start.Session,
+		"entries": drafts,
+	})
+}
+
+// respondRoutineError maps an error saving a routine to a response
+func respondRoutineError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRoutineExerciseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmptyRoutine),
+		errors.Is(err, services.ErrConflictingRoutineTargets),
+		errors.Is(err, services.ErrRoutinePercentageUnsupported),
+		errors.Is(err, services.ErrRoutineWeightUnsupported),
+		errors.Is(err, services.ErrRoutineRepsUnsupported):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// routineExercisesFromRequest converts a routine's exercises, keeping nil as nil so an
+// update without exercises leaves them alone
+func routineExercisesFromRequest(requests []RoutineExerciseRequest) []models.RoutineExercise {
+	if requests == nil {
+		return nil
+	}
+	exercises := make([]models.RoutineExercise, 0, len(requests))
+	for _, req := range requests {
+		exercises = append(exercises, models.RoutineExercise{
+			ExerciseID:       req.ExerciseID,
+			TargetSets:       req.TargetSets,
+			TargetReps:       req.TargetReps,
+			TargetWeight:     req.TargetWeight,
+			TargetPercentage: req.TargetPercentage,
+			Notes:            req.Notes,
+		})
+	}
+	return exercises
+}
+
+func workoutSetRequests(sets []models.WorkoutSet) []WorkoutSetRequest {
+	requests := make([]WorkoutSetRequest, 0, len(sets))
+	for _, set := range sets {
+		requests = append(requests, WorkoutSetRequest{
+			Type:     set.Type,
+			Weight:   set.Weight,
+			Reps:     set.Reps,
+			Duration: set.Duration,
+			Distance: set.Distance,
+			RPE:      set.RPE,
+			RIR:      set.RIR,
+		})
+	}
+	return requests
+}
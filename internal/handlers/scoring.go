@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"fitness-market/internal/scoring"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetScoringStrategies handles GET /api/v1/scoring/strategies
+func GetScoringStrategies(c *gin.Context) {
+	strategies := []gin.H{}
+	for _, s := range scoring.All() {
+		strategies = append(strategies, gin.H{
+			"name":        s.Name(),
+			"version":     s.Version(),
+			"description": s.Description(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"strategies": strategies,
+		"default":    scoring.Default,
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PushSyncRequest is a batch of changes a client made offline, applied in order
+type PushSyncRequest struct {
+	Changes []services.SyncChange `json:"changes" binding:"required"`
+}
+
+// GetSync handles GET /api/v1/sync. It returns the exercises, entries, bodyweight and PRs
+// created, updated or deleted since the since token, or everything when since is empty,
+// with the token to pass next time.
+func GetSync(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	pull, err := services.PullChanges(userID.(uint), c.Query("since"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSyncToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pull)
+}
+
+// PostSync handles POST /api/v1/sync. Each change is reported as created, updated,
+// deleted, conflict (the server's copy is newer and is returned instead), rejected, or
+// failed when the server could not apply it and it should be pushed again.
+func PostSync(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req PushSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := services.PushChanges(userID.(uint), req.Changes)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManySyncChanges) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"count":   len(results),
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultIndicatorRange is how far back indicator points go when no from date is given
+const defaultIndicatorRange = 90 * 24 * time.Hour
+
+// GetExerciseIndicators handles GET /api/v1/exercises/:id/indicators
+func GetExerciseIndicators(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	var exercise models.Exercise
+	if err := database.DB.Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	types, err := services.ParseIndicatorTypes(c.DefaultQuery("types", "sma20,ema10,rsi14,macd,bollinger"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format. Use YYYY-MM-DD"})
+			return
+		}
+		// Include the whole of the end day
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	from := to.Add(-defaultIndicatorRange)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format. Use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from date must not be after to date"})
+		return
+	}
+
+	result, err := services.GetExerciseIndicators(exercise.ID, types, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute indicators"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exercise_id": exercise.ID,
+		"ticker":      exercise.Ticker,
+		"types":       types,
+		"dates":       result.Dates,
+		"close":       result.Close,
+		"indicators":  result.Indicators,
+		"count":       len(result.Dates),
+	})
+}
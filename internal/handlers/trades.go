@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateTradeRequest struct {
+	ExerciseID uint    `json:"exercise_id" binding:"required"`
+	Side       string  `json:"side" binding:"required"`
+	Shares     float64 `json:"shares" binding:"required,gt=0"`
+}
+
+// CreateTrade handles POST /api/v1/trades
+func CreateTrade(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	side := strings.ToLower(strings.TrimSpace(req.Side))
+	trade, err := services.ExecuteTrade(userID.(uint), req.ExerciseID, side, req.Shares)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
+		case errors.Is(err, services.ErrInvalidTradeSide), errors.Is(err, services.ErrInvalidTradeShares):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInsufficientCash), errors.Is(err, services.ErrInsufficientShares),
+			errors.Is(err, services.ErrTickerDelisted):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTradingHalted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute trade"})
+		}
+		return
+	}
+
+	balance, err := services.GetCashBalance(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cash balance"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Trade executed successfully",
+		"trade":        trade,
+		"cash_balance": balance,
+	})
+}
+
+// GetTrades handles GET /api/v1/trades
+func GetTrades(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var exerciseID uint64
+	if exerciseIDStr := c.Query("exercise_id"); exerciseIDStr != "" {
+		var err error
+		exerciseID, err = strconv.ParseUint(exerciseIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+			return
+		}
+	}
+
+	trades, err := services.GetTrades(userID.(uint), uint(exerciseID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trades"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trades": trades,
+		"count":  len(trades),
+	})
+}
+
+// GetHoldings handles GET /api/v1/holdings
+func GetHoldings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	holdings, err := services.GetHoldings(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holdings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"holdings": holdings,
+		"count":    len(holdings),
+	})
+}
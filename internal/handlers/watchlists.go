@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateWatchlistRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AddWatchlistItemRequest struct {
+	ExerciseID uint `json:"exercise_id" binding:"required"`
+}
+
+// GetWatchlists handles GET /api/v1/watchlists
+func GetWatchlists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlists, err := services.GetWatchlists(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watchlists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"watchlists": watchlists,
+		"count":      len(watchlists),
+	})
+}
+
+// CreateWatchlist handles POST /api/v1/watchlists
+func CreateWatchlist(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateWatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	watchlist := models.Watchlist{
+		UserID: userID.(uint),
+		Name:   name,
+	}
+	if err := database.DB.Create(&watchlist).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create watchlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Watchlist created successfully",
+		"watchlist": watchlist,
+	})
+}
+
+// GetWatchlist handles GET /api/v1/watchlists/:id
+func GetWatchlist(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlistID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+		return
+	}
+
+	watchlist, err := services.GetWatchlist(userID.(uint), uint(watchlistID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watchlist": watchlist})
+}
+
+// DeleteWatchlist handles DELETE /api/v1/watchlists/:id
+func DeleteWatchlist(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlistID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+		return
+	}
+
+	if err := services.DeleteWatchlist(userID.(uint), uint(watchlistID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete watchlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Watchlist deleted successfully"})
+}
+
+// AddWatchlistItem handles POST /api/v1/watchlists/:id/items
+func AddWatchlistItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlistID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+		return
+	}
+
+	var req AddWatchlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := services.AddWatchlistItem(userID.(uint), uint(watchlistID), req.ExerciseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist or exercise not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add exercise to watchlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Exercise added to watchlist",
+		"item":    item,
+	})
+}
+
+// RemoveWatchlistItem handles DELETE /api/v1/watchlists/:id/items/:exercise_id
+func RemoveWatchlistItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlistID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watchlist ID"})
+		return
+	}
+	exerciseID, err := strconv.ParseUint(c.Param("exercise_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+
+	if err := services.RemoveWatchlistItem(userID.(uint), uint(watchlistID), uint(exerciseID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise is not on this watchlist"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove exercise from watchlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exercise removed from watchlist"})
+}
@@ -0,0 +1,580 @@
+// Package importer reads workout history exported by other apps as CSV. It detects the
+// column layout, converts units and set types, and returns one Row per set; it knows nothing
+// about the database.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts Parse understands
+const (
+	FormatStrong   = "strong"
+	FormatHevy     = "hevy"
+	FormatFitNotes = "fitnotes"
+	FormatGeneric  = "generic"
+)
+
+// Units for columns whose export doesn't say: metric is kilograms and kilometres, imperial
+// is pounds and miles
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Set types, matching the ones entries are logged with
+const (
+	SetTypeWarmup  = "warmup"
+	SetTypeWorking = "working"
+	SetTypeDrop    = "drop"
+	SetTypeFailure = "failure"
+)
+
+const (
+	kilogramsPerPound = 0.45359237
+	metresPerMile     = 1609.344
+)
+
+var (
+	ErrEmptyFile      = errors.New("the file has no rows")
+	ErrUnknownFormat  = errors.New("unrecognised CSV layout; expected a Strong, Hevy, FitNotes or generic export")
+	ErrInvalidFormat  = errors.New("format must be one of strong, hevy, fitnotes or generic")
+	ErrInvalidUnits   = errors.New("units must be metric or imperial")
+	ErrMissingColumns = errors.New("the file is missing required columns")
+	ErrUnreadableFile = errors.New("the file is not valid CSV")
+)
+
+// Options adjust how a file is read. Format forces a layout instead of detecting it.
+type Options struct {
+	Format string
+	Units  string
+}
+
+// Row is one set read from the file. Weight is in kilograms, Duration in seconds and
+// Distance in metres. Workout groups the sets logged in the same workout, and Sets is more
+// than one only for generic rows that stand for several identical sets.
+type Row struct {
+	Line     int
+	Workout  string
+	Date     time.Time
+	Exercise string
+	Category string
+	Type     string
+	Weight   float64
+	Reps     int
+	Sets     int
+	Duration int
+	Distance float64
+	RPE      *float64
+	RIR      *int
+	Notes    string
+}
+
+// RowError explains why a line of the file was skipped
+type RowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// Result is what Parse read from a file
+type Result struct {
+	Format    string
+	Rows      []Row
+	Errors    []RowError
+	TotalRows int
+}
+
+// Parse reads a CSV export. Lines that can't be read are reported in Result.Errors and
+// left out of Result.Rows; an error is only returned when the file as a whole is unusable.
+func Parse(r io.Reader, opts Options) (*Result, error) {
+	units := opts.Units
+	switch units {
+	case "":
+		units = UnitsMetric
+	case UnitsMetric, UnitsImperial:
+	default:
+		return nil, ErrInvalidUnits
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadableFile, err)
+	}
+	cols := newColumns(header)
+
+	format := opts.Format
+	switch format {
+	case "":
+		format = detectFormat(cols)
+		if format == "" {
+			return nil, ErrUnknownFormat
+		}
+	case FormatStrong, FormatHevy, FormatFitNotes, FormatGeneric:
+	default:
+		return nil, ErrInvalidFormat
+	}
+
+	parse := parsers[format]
+	if missing := cols.missing(requiredColumns[format]); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	result := &Result{Format: format}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			result.TotalRows++
+			result.Errors = append(result.Errors, RowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+		result.TotalRows++
+
+		row := Row{Line: line, Sets: 1}
+		if err := parse(cols.record(record), units, &row); err != nil {
+			result.Errors = append(result.Errors, RowError{Line: line, Message: err.Error()})
+			continue
+		}
+		if row.Exercise == "" {
+			result.Errors = append(result.Errors, RowError{Line: line, Message: "exercise name is empty"})
+			continue
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if result.TotalRows == 0 {
+		return nil, ErrEmptyFile
+	}
+	return result, nil
+}
+
+// requiredColumns are the columns each layout can't do without
+var requiredColumns = map[string][]string{
+	FormatStrong:   {"date", "exercise name"},
+	FormatHevy:     {"start_time", "exercise_title"},
+	FormatFitNotes: {"date", "exercise"},
+	FormatGeneric:  {"date", "exercise"},
+}
+
+var parsers = map[string]func(record, string, *Row) error{
+	FormatStrong:   parseStrong,
+	FormatHevy:     parseHevy,
+	FormatFitNotes: parseFitNotes,
+	FormatGeneric:  parseGeneric,
+}
+
+// detectFormat recognises a layout from its header
+func detectFormat(cols columns) string {
+	switch {
+	case cols.has("exercise_title") && cols.has("start_time"):
+		return FormatHevy
+	case cols.has("exercise name") && cols.has("set order"):
+		return FormatStrong
+	case cols.has("exercise") && cols.has("category") && (cols.has("weight (kgs)") || cols.has("weight (lbs)")):
+		return FormatFitNotes
+	case cols.has("date") && cols.has("exercise"):
+		return FormatGeneric
+	}
+	return ""
+}
+
+// parseStrong reads a Strong export, one set per row. Set Order is the set number, or W, D
+// or F for warmup, drop and failure sets. Weight and distance are in the units chosen in the
+// app unless the newer Weight Unit and Distance Unit columns say otherwise.
+func parseStrong(rec record, units string, row *Row) error {
+	var err error
+	if row.Date, err = parseDate(rec.get("date")); err != nil {
+		return err
+	}
+	row.Workout = rec.get("date") + "|" + rec.get("workout name")
+	row.Exercise = rec.get("exercise name")
+	row.Notes = rec.get("notes")
+
+	switch strings.ToUpper(rec.get("set order")) {
+	case "W":
+		row.Type = SetTypeWarmup
+	case "D":
+		row.Type = SetTypeDrop
+	case "F":
+		row.Type = SetTypeFailure
+	default:
+		row.Type = SetTypeWorking
+	}
+
+	weightUnit, distanceUnit := unitNames(units)
+	if unit := rec.get("weight unit"); unit != "" {
+		weightUnit = unit
+	}
+	if unit := rec.get("distance unit"); unit != "" {
+		distanceUnit = unit
+	}
+
+	if row.Weight, err = rec.weight("weight", weightUnit); err != nil {
+		return err
+	}
+	if row.Reps, err = rec.int("reps"); err != nil {
+		return err
+	}
+	if row.Duration, err = rec.int("seconds"); err != nil {
+		return err
+	}
+	if row.Distance, err = rec.distance("distance", distanceUnit); err != nil {
+		return err
+	}
+	row.RPE, err = rec.optionalFloat("rpe")
+	return err
+}
+
+// parseHevy reads a Hevy export, one set per row, with units in the column names
+func parseHevy(rec record, units string, row *Row) error {
+	var err error
+	if row.Date, err = parseDate(rec.get("start_time")); err != nil {
+		return err
+	}
+	row.Workout = rec.get("start_time") + "|" + rec.get("title")
+	row.Exercise = rec.get("exercise_title")
+	row.Notes = rec.get("exercise_notes")
+
+	switch strings.ToLower(rec.get("set_type")) {
+	case "warmup":
+		row.Type = SetTypeWarmup
+	case "dropset":
+		row.Type = SetTypeDrop
+	case "failure":
+		row.Type = SetTypeFailure
+	default:
+		row.Type = SetTypeWorking
+	}
+
+	if rec.has("weight_lbs") {
+		row.Weight, err = rec.weight("weight_lbs", "lbs")
+	} else {
+		row.Weight, err = rec.weight("weight_kg", "kg")
+	}
+	if err != nil {
+		return err
+	}
+	if rec.has("distance_miles") {
+		row.Distance, err = rec.distance("distance_miles", "mi")
+	} else {
+		row.Distance, err = rec.distance("distance_km", "km")
+	}
+	if err != nil {
+		return err
+	}
+	if row.Reps, err = rec.int("reps"); err != nil {
+		return err
+	}
+	if row.Duration, err = rec.int("duration_seconds"); err != nil {
+		return err
+	}
+	row.RPE, err = rec.optionalFloat("rpe")
+	return err
+}
+
+// parseFitNotes reads a FitNotes export, one set per row. The weight column's name carries
+// its unit, Distance Unit the distance's and Time is h:mm:ss.
+func parseFitNotes(rec record, units string, row *Row) error {
+	var err error
+	if row.Date, err = parseDate(rec.get("date")); err != nil {
+		return err
+	}
+	row.Workout = rec.get("date")
+	row.Exercise = rec.get("exercise")
+	row.Category = rec.get("category")
+	row.Notes = rec.get("comment")
+	row.Type = SetTypeWorking
+
+	if rec.has("weight (lbs)") {
+		row.Weight, err = rec.weight("weight (lbs)", "lbs")
+	} else {
+		row.Weight, err = rec.weight("weight (kgs)", "kg")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, distanceUnit := unitNames(units)
+	if unit := rec.get("distance unit"); unit != "" {
+		distanceUnit = unit
+	}
+	if row.Distance, err = rec.distance("distance", distanceUnit); err != nil {
+		return err
+	}
+	if row.Reps, err = rec.int("reps"); err != nil {
+		return err
+	}
+	row.Duration, err = parseClock(rec.get("time"))
+	return err
+}
+
+// parseGeneric reads the layout the entries API uses: date, exercise and any of category,
+// set_type, weight, reps, sets, duration (seconds), distance (metres), rpe, rir and notes.
+// Weight is in the chosen units. A row with sets stands for that many identical sets, and
+// every row of an exercise on the same day belongs to one entry.
+func parseGeneric(rec record, units string, row *Row) error {
+	var err error
+	if row.Date, err = parseDate(rec.get("date")); err != nil {
+		return err
+	}
+	row.Workout = row.Date.Format("2006-01-02")
+	row.Exercise = rec.get("exercise")
+	row.Category = rec.get("category")
+	row.Notes = rec.get("notes")
+
+	row.Type = strings.ToLower(rec.get("set_type"))
+	if row.Type == "" {
+		row.Type = SetTypeWorking
+	}
+
+	weightUnit, _ := unitNames(units)
+	if row.Weight, err = rec.weight("weight", weightUnit); err != nil {
+		return err
+	}
+	if row.Distance, err = rec.distance("distance", "m"); err != nil {
+		return err
+	}
+	if row.Reps, err = rec.int("reps"); err != nil {
+		return err
+	}
+	if row.Duration, err = rec.int("duration"); err != nil {
+		return err
+	}
+	if rec.get("sets") != "" {
+		if row.Sets, err = rec.int("sets"); err != nil {
+			return err
+		}
+		if row.Sets < 1 {
+			return errors.New("sets must be at least 1")
+		}
+	}
+	if row.RPE, err = rec.optionalFloat("rpe"); err != nil {
+		return err
+	}
+	if value := rec.get("rir"); value != "" {
+		rir, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("rir %q is not a whole number", value)
+		}
+		row.RIR = &rir
+	}
+	return nil
+}
+
+// dateLayouts are the date formats seen in exports, tried in order
+var dateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02",
+	"2 Jan 2006, 15:04",
+	"Jan 2, 2006, 15:04",
+	"2 Jan 2006",
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q is not in a recognised format", value)
+}
+
+// parseClock reads a duration written as seconds, m:ss or h:mm:ss
+func parseClock(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	seconds := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("time %q is not h:mm:ss", value)
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, nil
+}
+
+// unitNames returns the weight and distance units a units option stands for
+func unitNames(units string) (weight string, distance string) {
+	if units == UnitsImperial {
+		return "lbs", "mi"
+	}
+	return "kg", "km"
+}
+
+// detectDelimiter picks semicolons, which newer Strong exports use, when the header has
+// more of them than commas
+func detectDelimiter(data []byte) rune {
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// columns maps lowercased header names to their positions
+type columns map[string]int
+
+func newColumns(header []string) columns {
+	cols := columns{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cols[name]; !ok {
+			cols[name] = i
+		}
+	}
+	return cols
+}
+
+func (c columns) has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
+
+func (c columns) missing(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if !c.has(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func (c columns) record(fields []string) record {
+	return record{cols: c, fields: fields}
+}
+
+// record reads a line's fields by column name
+type record struct {
+	cols   columns
+	fields []string
+}
+
+func (r record) has(name string) bool {
+	return r.cols.has(name)
+}
+
+func (r record) get(name string) string {
+	i, ok := r.cols[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+func (r record) float(name string) (float64, error) {
+	value := r.get(name)
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s %q is not a valid number", name, value)
+	}
+	return f, nil
+}
+
+func (r record) optionalFloat(name string) (*float64, error) {
+	if r.get(name) == "" {
+		return nil, nil
+	}
+	f, err := r.float(name)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r record) int(name string) (int, error) {
+	f, err := r.float(name)
+	if err != nil {
+		return 0, err
+	}
+	if f != float64(int(f)) {
+		return 0, fmt.Errorf("%s %q is not a whole number", name, r.get(name))
+	}
+	return int(f), nil
+}
+
+// weight reads a weight in unit and converts it to kilograms
+func (r record) weight(name string, unit string) (float64, error) {
+	f, err := r.float(name)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "kg", "kgs":
+		return f, nil
+	case "lb", "lbs":
+		return roundTo(f*kilogramsPerPound, 100), nil
+	}
+	return 0, fmt.Errorf("weight unit %q is not kg or lbs", unit)
+}
+
+// distance reads a distance in unit and converts it to metres
+func (r record) distance(name string, unit string) (float64, error) {
+	f, err := r.float(name)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(unit) {
+	case "m", "metres", "meters":
+		return f, nil
+	case "km", "kilometres", "kilometers":
+		return roundTo(f*1000, 10), nil
+	case "mi", "mile", "miles":
+		return roundTo(f*metresPerMile, 10), nil
+	}
+	return 0, fmt.Errorf("distance unit %q is not m, km or mi", unit)
+}
+
+func roundTo(value float64, scale float64) float64 {
+	return float64(int64(value*scale+0.5)) / scale
+}
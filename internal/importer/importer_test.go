@@ -0,0 +1,315 @@
+package importer
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parse(t *testing.T, data string, opts Options) *Result {
+	t.Helper()
+	result, err := Parse(strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return result
+}
+
+func TestParseDetectsFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "strong",
+			data: "Date,Workout Name,Exercise Name,Set Order,Weight,Reps\n2024-01-05 07:30:00,Push,Bench Press,1,60,5\n",
+			want: FormatStrong,
+		},
+		{
+			name: "strong with semicolons",
+			data: "Date;Workout Name;Exercise Name;Set Order;Weight;Reps\n2024-01-05 07:30:00;Push;Bench Press;1;60;5\n",
+			want: FormatStrong,
+		},
+		{
+			name: "hevy",
+			data: "title,start_time,exercise_title,set_type,weight_kg,reps\nPush,2024-01-05 07:30,Bench Press,normal,60,5\n",
+			want: FormatHevy,
+		},
+		{
+			name: "fitnotes",
+			data: "Date,Exercise,Category,Weight (kgs),Reps\n2024-01-05,Bench Press,Chest,60,5\n",
+			want: FormatFitNotes,
+		},
+		{
+			name: "generic",
+			data: "date,exercise,weight,reps\n2024-01-05,Bench Press,60,5\n",
+			want: FormatGeneric,
+		},
+		{
+			name: "byte order mark and header case",
+			data: "\xef\xbb\xbfDATE,EXERCISE,WEIGHT,REPS\n2024-01-05,Bench Press,60,5\n",
+			want: FormatGeneric,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parse(t, tt.data, Options{})
+			if result.Format != tt.want {
+				t.Errorf("format = %q, want %q", result.Format, tt.want)
+			}
+			if len(result.Rows) != 1 || len(result.Errors) != 0 {
+				t.Fatalf("got %d rows and errors %v, want 1 row", len(result.Rows), result.Errors)
+			}
+			if result.Rows[0].Exercise != "Bench Press" {
+				t.Errorf("exercise = %q, want Bench Press", result.Rows[0].Exercise)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnusableFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		opts Options
+		want error
+	}{
+		{name: "empty", data: "", want: ErrEmptyFile},
+		{name: "header only", data: "date,exercise\n", want: ErrEmptyFile},
+		{name: "unknown layout", data: "when,what\n2024-01-05,Bench\n", want: ErrUnknownFormat},
+		{name: "missing columns", data: "date,weight\n2024-01-05,60\n", opts: Options{Format: FormatStrong}, want: ErrMissingColumns},
+		{name: "invalid format", data: "date,exercise\n2024-01-05,Bench\n", opts: Options{Format: "csv"}, want: ErrInvalidFormat},
+		{name: "invalid units", data: "date,exercise\n2024-01-05,Bench\n", opts: Options{Units: "stone"}, want: ErrInvalidUnits},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.data), tt.opts)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWeightUnits(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		opts Options
+		want float64
+	}{
+		{
+			name: "generic metric",
+			data: "date,exercise,weight,reps\n2024-01-05,Squat,100,5\n",
+			want: 100,
+		},
+		{
+			name: "generic imperial",
+			data: "date,exercise,weight,reps\n2024-01-05,Squat,225,5\n",
+			opts: Options{Units: UnitsImperial},
+			want: 102.06,
+		},
+		{
+			name: "strong follows the units option",
+			data: "Date,Exercise Name,Set Order,Weight,Reps\n2024-01-05,Squat,1,225,5\n",
+			opts: Options{Units: UnitsImperial},
+			want: 102.06,
+		},
+		{
+			name: "strong weight unit column wins",
+			data: "Date,Exercise Name,Set Order,Weight,Weight Unit,Reps\n2024-01-05,Squat,1,100,kg,5\n",
+			opts: Options{Units: UnitsImperial},
+			want: 100,
+		},
+		{
+			name: "hevy pounds column",
+			data: "title,start_time,exercise_title,weight_lbs,reps\nLegs,2024-01-05 07:30,Squat,45,5\n",
+			want: 20.41,
+		},
+		{
+			name: "fitnotes pounds column ignores the units option",
+			data: "Date,Exercise,Category,Weight (lbs),Reps\n2024-01-05,Squat,Legs,135,5\n",
+			opts: Options{Units: UnitsMetric},
+			want: 61.23,
+		},
+		{
+			name: "decimal comma",
+			data: "Date;Exercise Name;Set Order;Weight;Reps\n2024-01-05;Squat;1;102,5;5\n",
+			want: 102.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parse(t, tt.data, tt.opts)
+			if len(result.Rows) != 1 {
+				t.Fatalf("got %d rows, errors %v", len(result.Rows), result.Errors)
+			}
+			if got := result.Rows[0].Weight; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("weight = %v kg, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStrongSetOrder(t *testing.T) {
+	data := "Date,Workout Name,Exercise Name,Set Order,Weight,Reps\n" +
+		"2024-01-05 07:30:00,Push,Bench Press,W,40,10\n" +
+		"2024-01-05 07:30:00,Push,Bench Press,1,60,5\n" +
+		"2024-01-05 07:30:00,Push,Bench Press,2,60,5\n" +
+		"2024-01-05 07:30:00,Push,Bench Press,D,45,8\n" +
+		"2024-01-05 07:30:00,Push,Bench Press,f,60,3\n"
+
+	result := parse(t, data, Options{})
+	want := []string{SetTypeWarmup, SetTypeWorking, SetTypeWorking, SetTypeDrop, SetTypeFailure}
+	if len(result.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(result.Rows), len(want))
+	}
+	for i, row := range result.Rows {
+		if row.Type != want[i] {
+			t.Errorf("row %d type = %q, want %q", i, row.Type, want[i])
+		}
+		if row.Line != i+2 {
+			t.Errorf("row %d line = %d, want %d", i, row.Line, i+2)
+		}
+		if row.Workout != result.Rows[0].Workout {
+			t.Errorf("row %d workout = %q, want %q", i, row.Workout, result.Rows[0].Workout)
+		}
+	}
+}
+
+func TestParseFitNotes(t *testing.T) {
+	data := "Date,Exercise,Category,Weight (kgs),Reps,Distance,Distance Unit,Time,Comment\n" +
+		"2024-01-05,Rowing,Cardio,,,2,km,0:08:30,\n" +
+		"2024-01-05,Plank,Core,,,,,1:30,felt strong\n" +
+		"2024-01-06,Deadlift,Back,140,3,,,,\n" +
+		"2024-01-06,Rowing,Cardio,,,1,mi,8:00,\n"
+
+	result := parse(t, data, Options{})
+	if result.Format != FormatFitNotes {
+		t.Fatalf("format = %q, want fitnotes", result.Format)
+	}
+
+	tests := []struct {
+		exercise string
+		date     time.Time
+		weight   float64
+		reps     int
+		duration int
+		distance float64
+		notes    string
+	}{
+		{"Rowing", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), 0, 0, 510, 2000, ""},
+		{"Plank", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), 0, 0, 90, 0, "felt strong"},
+		{"Deadlift", time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), 140, 3, 0, 0, ""},
+		{"Rowing", time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), 0, 0, 480, 1609.3, ""},
+	}
+	if len(result.Rows) != len(tests) {
+		t.Fatalf("got %d rows, errors %v", len(result.Rows), result.Errors)
+	}
+	for i, tt := range tests {
+		row := result.Rows[i]
+		if row.Exercise != tt.exercise || !row.Date.Equal(tt.date) || row.Weight != tt.weight || row.Reps != tt.reps ||
+			row.Duration != tt.duration || row.Distance != tt.distance || row.Notes != tt.notes {
+			t.Errorf("row %d = %+v, want %+v", i, row, tt)
+		}
+		if row.Type != SetTypeWorking {
+			t.Errorf("row %d type = %q, want working", i, row.Type)
+		}
+	}
+}
+
+func TestParseReportsBadLines(t *testing.T) {
+	data := "date,exercise,weight,reps,sets\n" +
+		"2024-01-05,Squat,100,5,3\n" +
+		"05/01/2024,Squat,100,5,1\n" +
+		"2024-01-06,,100,5,1\n" +
+		"\n" +
+		"2024-01-07,Squat,heavy,5,1\n" +
+		"2024-01-08,Squat,100,5.5,1\n" +
+		"2024-01-09,Squat,100,5,0\n"
+
+	result := parse(t, data, Options{})
+	if len(result.Rows) != 1 || result.Rows[0].Sets != 3 {
+		t.Fatalf("rows = %+v, want one row of 3 sets", result.Rows)
+	}
+	if result.TotalRows != 6 {
+		t.Errorf("total rows = %d, want 6", result.TotalRows)
+	}
+	wantLines := []int{3, 4, 6, 7, 8}
+	if len(result.Errors) != len(wantLines) {
+		t.Fatalf("errors = %v, want lines %v", result.Errors, wantLines)
+	}
+	for i, line := range wantLines {
+		if result.Errors[i].Line != line {
+			t.Errorf("error %d line = %d, want %d", i, result.Errors[i].Line, line)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-01-05 07:30:15", time.Date(2024, 1, 5, 7, 30, 15, 0, time.UTC)},
+		{"2024-01-05 07:30", time.Date(2024, 1, 5, 7, 30, 0, 0, time.UTC)},
+		{"2024-01-05T07:30:00Z", time.Date(2024, 1, 5, 7, 30, 0, 0, time.UTC)},
+		{"2024-01-05", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"5 Jan 2024, 07:30", time.Date(2024, 1, 5, 7, 30, 0, 0, time.UTC)},
+		{"Jan 5, 2024, 07:30", time.Date(2024, 1, 5, 7, 30, 0, 0, time.UTC)},
+		{"5 Jan 2024", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDate(tt.value)
+			if err != nil {
+				t.Fatalf("parseDate(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "01/05/2024", "yesterday"} {
+		if _, err := parseDate(value); err == nil {
+			t.Errorf("parseDate(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "45", want: 45},
+		{value: "1:30", want: 90},
+		{value: "0:08:30", want: 510},
+		{value: "1:02:03", want: 3723},
+		{value: "1:-5", wantErr: true},
+		{value: "1:3o", wantErr: true},
+		{value: "1::30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseClock(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClock(%q) err = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseClock(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
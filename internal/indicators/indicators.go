@@ -0,0 +1,160 @@
+// Package indicators implements market-style technical indicators over a series of values,
+// oldest first. Every function returns slices the same length as its input; positions
+// still inside an indicator's warmup period hold NaN.
+package indicators
+
+import "math"
+
+// SMA returns the simple moving average of the last period values at each point
+func SMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 {
+		return out
+	}
+
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA returns the exponential moving average with smoothing 2/(period+1), seeded with the
+// simple average of the first period values
+func EMA(values []float64, period int) []float64 {
+	return emaFrom(values, period, 0)
+}
+
+// RSI returns Wilder's relative strength index, using his smoothed averages of gains and
+// losses over period changes. The first value appears once period changes are available.
+func RSI(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 || len(values) <= period {
+		return out
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		gain, loss := change(values[i-1], values[i])
+		avgGain += gain
+		avgLoss += loss
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	out[period] = rsi(avgGain, avgLoss)
+
+	for i := period + 1; i < len(values); i++ {
+		gain, loss := change(values[i-1], values[i])
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		out[i] = rsi(avgGain, avgLoss)
+	}
+	return out
+}
+
+// MACD returns the difference between the fast and slow EMAs, the signal EMA of that line
+// and the histogram of their difference. The conventional periods are 12, 26 and 9.
+func MACD(values []float64, fast int, slow int, signal int) (macd []float64, signalLine []float64, histogram []float64) {
+	fastEMA := EMA(values, fast)
+	slowEMA := EMA(values, slow)
+
+	macd = nanSlice(len(values))
+	start := -1
+	for i := range values {
+		if math.IsNaN(fastEMA[i]) || math.IsNaN(slowEMA[i]) {
+			continue
+		}
+		macd[i] = fastEMA[i] - slowEMA[i]
+		if start < 0 {
+			start = i
+		}
+	}
+
+	signalLine = nanSlice(len(values))
+	if start >= 0 {
+		signalLine = emaFrom(macd, signal, start)
+	}
+
+	histogram = nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(signalLine[i]) {
+			histogram[i] = macd[i] - signalLine[i]
+		}
+	}
+	return macd, signalLine, histogram
+}
+
+// Bollinger returns the period SMA with bands k population standard deviations above and
+// below it. The conventional settings are 20 and 2.
+func Bollinger(values []float64, period int, k float64) (middle []float64, upper []float64, lower []float64) {
+	middle = SMA(values, period)
+	upper = nanSlice(len(values))
+	lower = nanSlice(len(values))
+
+	for i := range values {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+		var variance float64
+		for _, v := range values[i-period+1 : i+1] {
+			variance += (v - middle[i]) * (v - middle[i])
+		}
+		deviation := math.Sqrt(variance / float64(period))
+		upper[i] = middle[i] + k*deviation
+		lower[i] = middle[i] - k*deviation
+	}
+	return middle, upper, lower
+}
+
+// emaFrom computes an EMA over values[start:], leaving everything before start as NaN
+func emaFrom(values []float64, period int, start int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 || len(values)-start < period {
+		return out
+	}
+
+	var seed float64
+	for _, v := range values[start : start+period] {
+		seed += v
+	}
+	ema := seed / float64(period)
+	out[start+period-1] = ema
+
+	alpha := 2 / float64(period+1)
+	for i := start + period; i < len(values); i++ {
+		ema = alpha*values[i] + (1-alpha)*ema
+		out[i] = ema
+	}
+	return out
+}
+
+func change(previous float64, current float64) (gain float64, loss float64) {
+	if current > previous {
+		return current - previous, 0
+	}
+	return 0, previous - current
+}
+
+func rsi(avgGain float64, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+func nanSlice(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
@@ -0,0 +1,234 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// closes is the 33-day close series from Wilder's RSI worked example
+var closes = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+	43.42, 42.66, 43.13,
+}
+
+const tolerance = 1e-4
+
+// expectation is the value an indicator should hold at one index; NaN means still warming up
+type expectation struct {
+	index int
+	want  float64
+}
+
+func checkSeries(t *testing.T, name string, got []float64, length int, expected []expectation) {
+	t.Helper()
+	if len(got) != length {
+		t.Fatalf("%s: got %d values, want %d", name, len(got), length)
+	}
+	for _, e := range expected {
+		value := got[e.index]
+		if math.IsNaN(e.want) {
+			if !math.IsNaN(value) {
+				t.Errorf("%s[%d] = %v, want NaN", name, e.index, value)
+			}
+			continue
+		}
+		if math.Abs(value-e.want) > tolerance {
+			t.Errorf("%s[%d] = %.6f, want %.4f", name, e.index, value, e.want)
+		}
+	}
+}
+
+func TestSMA(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		period   int
+		expected []expectation
+	}{
+		{
+			name:     "ascending",
+			values:   []float64{1, 2, 3, 4, 5, 6},
+			period:   3,
+			expected: []expectation{{0, math.NaN()}, {1, math.NaN()}, {2, 2}, {3, 3}, {5, 5}},
+		},
+		{
+			name:     "period of one is the input",
+			values:   []float64{7, 3, 9},
+			period:   1,
+			expected: []expectation{{0, 7}, {1, 3}, {2, 9}},
+		},
+		{
+			name:     "period longer than series",
+			values:   []float64{1, 2},
+			period:   5,
+			expected: []expectation{{0, math.NaN()}, {1, math.NaN()}},
+		},
+		{
+			name:     "reference closes",
+			values:   closes,
+			period:   20,
+			expected: []expectation{{18, math.NaN()}, {19, 45.409}, {32, 45.241}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSeries(t, "sma", SMA(tt.values, tt.period), len(tt.values), tt.expected)
+		})
+	}
+}
+
+func TestEMA(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		period   int
+		expected []expectation
+	}{
+		{
+			name:     "seeded with simple average",
+			values:   []float64{2, 4, 6, 8, 10},
+			period:   3,
+			expected: []expectation{{1, math.NaN()}, {2, 4}, {3, 6}, {4, 8}},
+		},
+		{
+			name:     "flat series",
+			values:   []float64{5, 5, 5, 5},
+			period:   2,
+			expected: []expectation{{0, math.NaN()}, {1, 5}, {3, 5}},
+		},
+		{
+			name:     "reference closes",
+			values:   closes,
+			period:   10,
+			expected: []expectation{{8, math.NaN()}, {9, 44.779}, {10, 44.981}, {20, 45.9321}, {32, 44.1193}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSeries(t, "ema", EMA(tt.values, tt.period), len(tt.values), tt.expected)
+		})
+	}
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		period   int
+		expected []expectation
+	}{
+		{
+			name:     "only gains",
+			values:   []float64{1, 2, 3, 4},
+			period:   2,
+			expected: []expectation{{1, math.NaN()}, {2, 100}, {3, 100}},
+		},
+		{
+			name:     "only losses",
+			values:   []float64{4, 3, 2, 1},
+			period:   2,
+			expected: []expectation{{2, 0}, {3, 0}},
+		},
+		{
+			name:     "no movement",
+			values:   []float64{3, 3, 3},
+			period:   2,
+			expected: []expectation{{2, 50}},
+		},
+		{
+			name:     "reference closes",
+			values:   closes,
+			period:   14,
+			expected: []expectation{{13, math.NaN()}, {14, 70.4641}, {15, 66.2496}, {20, 62.8807}, {32, 37.7888}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSeries(t, "rsi", RSI(tt.values, tt.period), len(tt.values), tt.expected)
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	tests := []struct {
+		name              string
+		values            []float64
+		fast, slow, sig   int
+		macd, signal, hst []expectation
+	}{
+		{
+			name:   "reference closes",
+			values: closes,
+			fast:   3, slow: 6, sig: 4,
+			macd:   []expectation{{4, math.NaN()}, {5, 0.2479}, {8, 0.4138}, {32, -0.4377}},
+			signal: []expectation{{7, math.NaN()}, {8, 0.3328}, {9, 0.3701}, {32, -0.4352}},
+			hst:    []expectation{{7, math.NaN()}, {8, 0.4138 - 0.3328}, {32, -0.4377 + 0.4352}},
+		},
+		{
+			name:   "too short for a signal line",
+			values: []float64{1, 2, 3, 4},
+			fast:   2, slow: 3, sig: 3,
+			macd:   []expectation{{1, math.NaN()}, {2, 0.5}, {3, 0.5}},
+			signal: []expectation{{3, math.NaN()}},
+			hst:    []expectation{{3, math.NaN()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			macd, signal, histogram := MACD(tt.values, tt.fast, tt.slow, tt.sig)
+			checkSeries(t, "macd", macd, len(tt.values), tt.macd)
+			checkSeries(t, "signal", signal, len(tt.values), tt.signal)
+			checkSeries(t, "histogram", histogram, len(tt.values), tt.hst)
+		})
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	tests := []struct {
+		name                 string
+		values               []float64
+		period               int
+		k                    float64
+		middle, upper, lower []expectation
+	}{
+		{
+			name:   "flat series has no band width",
+			values: []float64{4, 4, 4},
+			period: 2, k: 2,
+			middle: []expectation{{0, math.NaN()}, {1, 4}, {2, 4}},
+			upper:  []expectation{{1, 4}},
+			lower:  []expectation{{1, 4}},
+		},
+		{
+			name:   "population deviation",
+			values: []float64{2, 4},
+			period: 2, k: 1,
+			middle: []expectation{{1, 3}},
+			upper:  []expectation{{1, 4}},
+			lower:  []expectation{{1, 2}},
+		},
+		{
+			name:   "reference closes",
+			values: closes,
+			period: 20, k: 2,
+			middle: []expectation{{18, math.NaN()}, {19, 45.409}, {32, 45.241}},
+			upper:  []expectation{{19, 47.1153}, {32, 47.6202}},
+			lower:  []expectation{{19, 43.7027}, {32, 42.8618}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middle, upper, lower := Bollinger(tt.values, tt.period, tt.k)
+			checkSeries(t, "middle", middle, len(tt.values), tt.middle)
+			checkSeries(t, "upper", upper, len(tt.values), tt.upper)
+			checkSeries(t, "lower", lower, len(tt.values), tt.lower)
+		})
+	}
+}
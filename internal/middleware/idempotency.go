@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"fitness-market/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header that makes a request safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayHeader is set on responses replayed from an earlier request
+const IdempotentReplayHeader = "Idempotent-Replayed"
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a route honour the Idempotency-Key header. The first request with a key
+// is handled and its response stored; a retry with the same key and body gets the stored
+// response back, while reusing the key for a different body is rejected with 422. Requests
+// without the header are handled as usual. It must run after AuthMiddleware.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		method, path := c.Request.Method, c.Request.URL.Path
+		stored, err := services.BeginIdempotentRequest(userID.(uint), key, method, path, services.HashRequest(method, path, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyTooLong):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrIdempotencyKeyInFlight):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			}
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			c.Header(IdempotentReplayHeader, "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// A handler that panics never answers, so its claim is released for the retry
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := services.ReleaseIdempotentRequest(userID.(uint), key); err != nil {
+				log.Printf("Failed to release Idempotency-Key for user %v: %v", userID, err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors aren't stored so the client can retry them with the same key
+		status := recorder.Status()
+		if status < http.StatusInternalServerError {
+			err = services.CompleteIdempotentRequest(userID.(uint), key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+			if err != nil {
+				log.Printf("Failed to save Idempotency-Key for user %v: %v", userID, err)
+			}
+			completed = err == nil
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlertRule watches one exercise ticker and raises a notification when its condition is met.
+// Threshold is a price for price_above/price_below and a percentage for pct_move; WindowDays
+// is the lookback for pct_move and the number of days without an entry for no_entry.
+type AlertRule struct {
+	ID              uint           `json:"id" gorm:"primarykey"`
+	UserID          uint           `json:"user_id" gorm:"index;not null"`
+	ExerciseID      uint           `json:"exercise_id" gorm:"index;not null"`
+	Type            string         `json:"type" gorm:"not null"`
+	Threshold       float64        `json:"threshold" gorm:"not null;default:0"`
+	WindowDays      int            `json:"window_days" gorm:"not null;default:0"`
+	Active          bool           `json:"active" gorm:"not null;default:true"`
+	LastTriggeredAt *time.Time     `json:"last_triggered_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+}
+
+// Notification is a message in a user's in-app inbox. It is unread until ReadAt is set.
+type Notification struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	UserID      uint           `json:"user_id" gorm:"index;not null"`
+	AlertRuleID *uint          `json:"alert_rule_id"`
+	ExerciseID  *uint          `json:"exercise_id"`
+	Title       string         `json:"title" gorm:"not null"`
+	Message     string         `json:"message" gorm:"not null"`
+	ReadAt      *time.Time     `json:"read_at"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
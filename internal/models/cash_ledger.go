@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CashLedgerEntry is a single movement of a user's virtual cash balance. A user has at most
+// one deposit, their starting cash.
+type CashLedgerEntry struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	UserID      uint           `json:"user_id" gorm:"index;not null;uniqueIndex:idx_cash_ledger_entries_starting_cash,where:type = 'deposit'"`
+	Type        string         `json:"type" gorm:"not null;index"`
+	Amount      float64        `json:"amount" gorm:"not null"`
+	Balance     float64        `json:"balance" gorm:"not null"`
+	ExerciseID  *uint          `json:"exercise_id,omitempty" gorm:"index"`
+	Description string         `json:"description"`
+	OccurredAt  time.Time      `json:"occurred_at" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (CashLedgerEntry) TableName() string {
+	return "cash_ledger_entries"
+}
+
+// Dividend records a consistency dividend paid on a ticker for one week of training
+type Dividend struct {
+	ID            uint           `json:"id" gorm:"primarykey"`
+	UserID        uint           `json:"user_id" gorm:"index;not null"`
+	ExerciseID    uint           `json:"exercise_id" gorm:"not null;uniqueIndex:idx_dividends_exercise_period"`
+	PeriodStart   time.Time      `json:"period_start" gorm:"not null;uniqueIndex:idx_dividends_exercise_period"`
+	PeriodEnd     time.Time      `json:"period_end" gorm:"not null"`
+	EntryCount    int            `json:"entry_count" gorm:"not null"`
+	StockPrice    float64        `json:"stock_price" gorm:"not null"`
+	Amount        float64        `json:"amount" gorm:"not null"`
+	LedgerEntryID uint           `json:"ledger_entry_id" gorm:"not null"`
+	PaidAt        time.Time      `json:"paid_at" gorm:"not null"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+}
+
+func (Dividend) TableName() string {
+	return "dividends"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CatalogExercise is a curated exercise shared by all users. User exercises that link to
+// the same catalog entry can be compared with each other regardless of their own tickers.
+type CatalogExercise struct {
+	ID               uint           `json:"id" gorm:"primarykey"`
+	Ticker           string         `json:"ticker" gorm:"not null;uniqueIndex"`
+	Name             string         `json:"name" gorm:"not null"`
+	Description      string         `json:"description"`
+	Category         string         `json:"category" gorm:"not null;index"`
+	PrimaryMuscles   []string       `json:"primary_muscles" gorm:"serializer:json"`
+	SecondaryMuscles []string       `json:"secondary_muscles" gorm:"serializer:json"`
+	Equipment        string         `json:"equipment"`
+	MeasurementType  string         `json:"measurement_type" gorm:"not null;default:'weight_reps'"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CorporateAction records a split or reverse split of an exercise's stock.
+// Ratio is the number of new shares per old share: 10 for a 10-for-1 split, 0.1 for a 1-for-10 reverse split.
+type CorporateAction struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	UserID      uint           `json:"user_id" gorm:"index;not null"`
+	ExerciseID  uint           `json:"exercise_id" gorm:"index;not null"`
+	Type        string         `json:"type" gorm:"not null"`
+	Ratio       float64        `json:"ratio" gorm:"not null"`
+	PriceBefore float64        `json:"price_before" gorm:"not null"`
+	PriceAfter  float64        `json:"price_after" gorm:"not null"`
+	Trigger     string         `json:"trigger" gorm:"not null"`
+	EffectiveAt time.Time      `json:"effective_at" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	Exercise Exercise `json:"-" gorm:"foreignKey:ExerciseID"`
+}
+
+func (CorporateAction) TableName() string {
+	return "corporate_actions"
+}
@@ -4,24 +4,30 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Exercise struct {
-	ID          uint           `json:"id" gorm:"primarykey"`
-	UserID      uint           `json:"user_id" gorm:"not null;index"`
-	Ticker      string         `json:"ticker" gorm:"not null;index"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Category    string         `json:"category" gorm:"not null"`
-	StockPrice  float64        `json:"stock_price" gorm:"not null;default:0"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primarykey"`
+	UserID            uint           `json:"user_id" gorm:"not null;index;uniqueIndex:idx_exercises_user_uuid"`
+	UUID              string         `json:"uuid" gorm:"uniqueIndex:idx_exercises_user_uuid"`
+	Ticker            string         `json:"ticker" gorm:"not null;index"`
+	Name              string         `json:"name" gorm:"not null"`
+	Description       string         `json:"description"`
+	Category          string         `json:"category" gorm:"not null"`
+	StockPrice        float64        `json:"stock_price" gorm:"not null;default:0"`
+	CatalogExerciseID *uint          `json:"catalog_exercise_id" gorm:"index"`
+	ScoringStrategy   string         `json:"scoring_strategy"`
+	MeasurementType   string         `json:"measurement_type" gorm:"not null;default:'weight_reps'"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	User           User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	WorkoutEntries []WorkoutEntry `json:"workout_entries,omitempty" gorm:"foreignKey:ExerciseID"`
+	User            User             `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	WorkoutEntries  []WorkoutEntry   `json:"workout_entries,omitempty" gorm:"foreignKey:ExerciseID"`
+	CatalogExercise *CatalogExercise `json:"catalog_exercise,omitempty" gorm:"foreignKey:CatalogExerciseID"`
 }
 
 // TableName specifies the table name for Exercise
@@ -29,8 +35,11 @@ func (Exercise) TableName() string {
 	return "exercises"
 }
 
-// BeforeCreate hook to ensure unique ticker per user
+// BeforeCreate hook to ensure unique ticker per user and give the exercise a UUID
 func (e *Exercise) BeforeCreate(tx *gorm.DB) error {
+	if e.UUID == "" {
+		e.UUID = uuid.NewString()
+	}
 	var count int64
 	tx.Model(&Exercise{}).Where("user_id = ? AND ticker = ?", e.UserID, e.Ticker).Count(&count)
 	if count > 0 {
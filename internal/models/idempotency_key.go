@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey remembers a mutating request sent with an Idempotency-Key header and the
+// response it got, so a retry is answered with the same response instead of being applied
+// twice. StatusCode is 0 while the first request is still being handled.
+type IdempotencyKey struct {
+	ID           uint           `json:"id" gorm:"primarykey"`
+	UserID       uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key          string         `json:"key" gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Method       string         `json:"method" gorm:"not null"`
+	Path         string         `json:"path" gorm:"not null"`
+	RequestHash  string         `json:"request_hash" gorm:"not null"`
+	StatusCode   int            `json:"status_code" gorm:"not null;default:0"`
+	ContentType  string         `json:"content_type"`
+	ResponseBody []byte         `json:"-"`
+	ExpiresAt    time.Time      `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
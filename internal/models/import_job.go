@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportJob tracks one upload of workout history exported from another app, or of an
+// export archive being restored. A dry run reports what the import would do without saving
+// anything. Errors lists the lines that were skipped, Exercises how each exercise name in the
+// file was mapped and Restored how many records of each table an archive restored.
+type ImportJob struct {
+	ID               uint                `json:"id" gorm:"primarykey"`
+	UserID           uint                `json:"user_id" gorm:"index;not null"`
+	Filename         string              `json:"filename"`
+	Format           string              `json:"format"`
+	Units            string              `json:"units"`
+	DryRun           bool                `json:"dry_run" gorm:"not null;default:false"`
+	Status           string              `json:"status" gorm:"not null;index"`
+	TotalRows        int                 `json:"total_rows" gorm:"not null;default:0"`
+	EntryCount       int                 `json:"entry_count" gorm:"not null;default:0"`
+	SetCount         int                 `json:"set_count" gorm:"not null;default:0"`
+	DuplicateCount   int                 `json:"duplicate_count" gorm:"not null;default:0"`
+	ErrorCount       int                 `json:"error_count" gorm:"not null;default:0"`
+	CreatedExercises int                 `json:"created_exercises" gorm:"not null;default:0"`
+	Restored         map[string]int      `json:"restored,omitempty" gorm:"serializer:json"`
+	Exercises        []ImportJobExercise `json:"exercises" gorm:"serializer:json"`
+	Errors           []ImportJobError    `json:"errors" gorm:"serializer:json"`
+	Failure          string              `json:"failure,omitempty"`
+	StartedAt        *time.Time          `json:"started_at"`
+	FinishedAt       *time.Time          `json:"finished_at"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// ImportJobExercise is how an exercise name in an import was mapped to one of the user's
+// exercises. ExerciseID is 0 for an exercise a dry run would create.
+type ImportJobExercise struct {
+	Name            string `json:"name"`
+	ExerciseID      uint   `json:"exercise_id"`
+	Ticker          string `json:"ticker"`
+	MeasurementType string `json:"measurement_type"`
+	Created         bool   `json:"created"`
+	EntryCount      int    `json:"entry_count"`
+}
+
+// ImportJobError is a line of an import that was skipped and why
+type ImportJobError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IndexValue is the closing level of one of a user's market indices on a given day
+type IndexValue struct {
+	ID           uint           `json:"id" gorm:"primarykey"`
+	UserID       uint           `json:"user_id" gorm:"not null;index"`
+	Symbol       string         `json:"symbol" gorm:"not null;index"`
+	Date         time.Time      `json:"date" gorm:"not null;index"`
+	Value        float64        `json:"value" gorm:"not null"`
+	Constituents int            `json:"constituents" gorm:"not null;default:0"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (IndexValue) TableName() string {
+	return "index_values"
+}
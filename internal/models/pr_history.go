@@ -6,16 +6,23 @@ import (
 	"gorm.io/gorm"
 )
 
-// PRHistory maintains the history of personal records per exercise
+// PRHistory maintains the history of personal records per exercise.
+// Type is the kind of record (score, weight, e1rm, rep_max, volume, max_reps, duration or
+// distance) and Value the measurement that set it; rep_max records are kept separately for
+// each rep count.
 type PRHistory struct {
 	ID             uint           `json:"id" gorm:"primarykey"`
 	UserID         uint           `json:"user_id" gorm:"index;not null"`
 	ExerciseID     uint           `json:"exercise_id" gorm:"index;not null"`
 	WorkoutEntryID uint           `json:"workout_entry_id" gorm:"index;not null"`
+	Type           string         `json:"type" gorm:"not null;default:'score';index"`
+	Value          float64        `json:"value" gorm:"not null;default:0"`
 	Score          float64        `json:"score" gorm:"not null"`
 	Weight         float64        `json:"weight" gorm:"not null"`
 	Reps           int            `json:"reps" gorm:"not null"`
 	Sets           int            `json:"sets" gorm:"not null"`
+	Duration       int            `json:"duration" gorm:"not null;default:0"`
+	Distance       float64        `json:"distance" gorm:"not null;default:0"`
 	AchievedAt     time.Time      `json:"achieved_at" gorm:"not null"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
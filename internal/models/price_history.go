@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceHistory records every change to an exercise's stock price
+type PriceHistory struct {
+	ID            uint           `json:"id" gorm:"primarykey"`
+	UserID        uint           `json:"user_id" gorm:"index;not null"`
+	ExerciseID    uint           `json:"exercise_id" gorm:"index;not null"`
+	Price         float64        `json:"price" gorm:"not null"`
+	PreviousPrice float64        `json:"previous_price" gorm:"not null"`
+	Reason        string         `json:"reason" gorm:"not null"`
+	RecordedAt    time.Time      `json:"recorded_at" gorm:"not null"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	Exercise Exercise `json:"-" gorm:"foreignKey:ExerciseID"`
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
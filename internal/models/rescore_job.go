@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RescoreJob tracks the background rescoring of some of a user's exercises after a change
+// that affects their scores, such as bodyweight logged in the past. ExerciseIDs are the
+// exercises to rescore and RescoredCount how many of them are done.
+type RescoreJob struct {
+	ID            uint           `json:"id" gorm:"primarykey"`
+	UserID        uint           `json:"user_id" gorm:"index;not null"`
+	Reason        string         `json:"reason" gorm:"not null"`
+	Status        string         `json:"status" gorm:"not null;index"`
+	ExerciseIDs   []uint         `json:"exercise_ids" gorm:"serializer:json"`
+	RescoredCount int            `json:"rescored_count" gorm:"not null;default:0"`
+	Failure       string         `json:"failure,omitempty"`
+	StartedAt     *time.Time     `json:"started_at"`
+	FinishedAt    *time.Time     `json:"finished_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (RescoreJob) TableName() string {
+	return "rescore_jobs"
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Routine is a workout template, such as a Push, Pull or Legs day, that sessions are
+// started from
+type Routine struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	UserID    uint           `json:"user_id" gorm:"index;not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	Notes     string         `json:"notes"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User      User              `json:"-" gorm:"foreignKey:UserID"`
+	Exercises []RoutineExercise `json:"exercises" gorm:"foreignKey:RoutineID"`
+}
+
+func (Routine) TableName() string {
+	return "routines"
+}
+
+// RoutineExercise is one exercise of a routine, done in Position order. TargetSets sets of
+// TargetReps at TargetWeight, or at TargetPercentage of the exercise's estimated one-rep
+// max; targets left at zero or nil are filled in from the exercise's latest entry.
+type RoutineExercise struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	RoutineID        uint      `json:"routine_id" gorm:"not null;index"`
+	ExerciseID       uint      `json:"exercise_id" gorm:"not null;index"`
+	Position         int       `json:"position" gorm:"not null"`
+	TargetSets       int       `json:"target_sets" gorm:"not null;default:0"`
+	TargetReps       int       `json:"target_reps" gorm:"not null;default:0"`
+	TargetWeight     *float64  `json:"target_weight"`
+	TargetPercentage *float64  `json:"target_percentage"`
+	Notes            string    `json:"notes"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relationships
+	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+}
+
+func (RoutineExercise) TableName() string {
+	return "routine_exercises"
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Holding is the number of shares a user owns in one of their exercise tickers
+type Holding struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	UserID      uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_holdings_user_exercise"`
+	ExerciseID  uint           `json:"exercise_id" gorm:"not null;uniqueIndex:idx_holdings_user_exercise"`
+	Shares      float64        `json:"shares" gorm:"not null;default:0"`
+	AverageCost float64        `json:"average_cost" gorm:"not null;default:0"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+}
+
+// Trade is a single buy or sell of shares at the exercise's stock price at the time
+type Trade struct {
+	ID            uint           `json:"id" gorm:"primarykey"`
+	UserID        uint           `json:"user_id" gorm:"index;not null"`
+	ExerciseID    uint           `json:"exercise_id" gorm:"index;not null"`
+	Side          string         `json:"side" gorm:"not null"`
+	Shares        float64        `json:"shares" gorm:"not null"`
+	Price         float64        `json:"price" gorm:"not null"`
+	Amount        float64        `json:"amount" gorm:"not null"`
+	LedgerEntryID uint           `json:"ledger_entry_id" gorm:"not null"`
+	ExecutedAt    time.Time      `json:"executed_at" gorm:"not null;index"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+}
@@ -3,28 +3,42 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserProfile struct {
-	ID        uint           `json:"id" gorm:"primarykey"`
-	UserID    uint           `json:"user_id" gorm:"uniqueIndex;not null"`
-	User      User           `json:"-" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primarykey"`
+	UserID          uint           `json:"user_id" gorm:"uniqueIndex;not null"`
+	User            User           `json:"-" gorm:"foreignKey:UserID"`
+	Timezone        string         `json:"timezone" gorm:"not null;default:'UTC'"`
+	Sex             string         `json:"sex"`
+	ScoringStrategy string         `json:"scoring_strategy" gorm:"not null;default:'volume'"`
+	PlateIncrement  float64        `json:"plate_increment" gorm:"not null;default:2.5"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type BodyweightEntry struct {
-	ID        uint           `json:"id" gorm:"primarykey"`
-	UserID    uint           `json:"user_id" gorm:"index;not null"`
-	User      User           `json:"-" gorm:"foreignKey:UserID"`
-	Weight    float64        `json:"weight" gorm:"not null"`
-	Unit      string         `json:"unit" gorm:"default:'kg'"`
-	RecordedAt time.Time    `json:"recorded_at" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID         uint           `json:"id" gorm:"primarykey"`
+	UserID     uint           `json:"user_id" gorm:"index;not null;uniqueIndex:idx_bodyweight_entries_user_uuid"`
+	UUID       string         `json:"uuid" gorm:"uniqueIndex:idx_bodyweight_entries_user_uuid"`
+	User       User           `json:"-" gorm:"foreignKey:UserID"`
+	Weight     float64        `json:"weight" gorm:"not null"`
+	Unit       string         `json:"unit" gorm:"default:'kg'"`
+	RecordedAt time.Time      `json:"recorded_at" gorm:"not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeCreate gives the bodyweight entry a UUID unless the client that logged it chose one
+func (b *BodyweightEntry) BeforeCreate(tx *gorm.DB) error {
+	if b.UUID == "" {
+		b.UUID = uuid.NewString()
+	}
+	return nil
 }
 
 type ExercisePR struct {
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Watchlist is a named list of exercise tickers a user wants to keep an eye on
+type Watchlist struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	UserID    uint           `json:"user_id" gorm:"index;not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User  User            `json:"-" gorm:"foreignKey:UserID"`
+	Items []WatchlistItem `json:"items,omitempty" gorm:"foreignKey:WatchlistID"`
+}
+
+// WatchlistItem is a single ticker on a watchlist
+type WatchlistItem struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	WatchlistID uint      `json:"watchlist_id" gorm:"not null;uniqueIndex:idx_watchlist_items_watchlist_exercise"`
+	ExerciseID  uint      `json:"exercise_id" gorm:"not null;uniqueIndex:idx_watchlist_items_watchlist_exercise"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relationships
+	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+}
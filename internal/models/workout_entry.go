@@ -3,29 +3,51 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// WorkoutEntry is one logged performance of an exercise, made up of its WorkoutSets.
+// Weight, Reps, Duration (seconds) and Distance (metres) summarise the top working set and
+// Sets counts the working sets; which are used depends on the exercise's measurement type.
+// RPE or RIR is the effort of any set that doesn't record its own.
 type WorkoutEntry struct {
-	ID         uint           `json:"id" gorm:"primarykey"`
-	UserID     uint           `json:"user_id" gorm:"index;not null"`
-	ExerciseID uint           `json:"exercise_id" gorm:"index;not null"`
-	Weight     float64        `json:"weight" gorm:"not null"`
-	Reps       int            `json:"reps" gorm:"not null"`
-	Sets       int            `json:"sets" gorm:"not null"`
-	Notes      string         `json:"notes"`
-	Date       time.Time      `json:"date" gorm:"not null"`
-	Score      float64        `json:"score" gorm:"not null;default:0"`
-	IsPR       bool           `json:"is_pr" gorm:"not null;default:false"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primarykey"`
+	UserID          uint           `json:"user_id" gorm:"index;not null;uniqueIndex:idx_workout_entries_user_uuid"`
+	UUID            string         `json:"uuid" gorm:"uniqueIndex:idx_workout_entries_user_uuid"`
+	ExerciseID      uint           `json:"exercise_id" gorm:"index;not null"`
+	SessionID       *uint          `json:"session_id" gorm:"index"`
+	Weight          float64        `json:"weight" gorm:"not null"`
+	Reps            int            `json:"reps" gorm:"not null"`
+	Sets            int            `json:"sets" gorm:"not null"`
+	Duration        int            `json:"duration" gorm:"not null;default:0"`
+	Distance        float64        `json:"distance" gorm:"not null;default:0"`
+	RPE             *float64       `json:"rpe"`
+	RIR             *int           `json:"rir"`
+	Notes           string         `json:"notes"`
+	Date            time.Time      `json:"date" gorm:"not null"`
+	Score           float64        `json:"score" gorm:"not null;default:0"`
+	IsPR            bool           `json:"is_pr" gorm:"not null;default:false"`
+	ScoringStrategy string         `json:"scoring_strategy" gorm:"not null;default:'volume'"`
+	ScoringVersion  int            `json:"scoring_version" gorm:"not null;default:1"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	User     User     `json:"-" gorm:"foreignKey:UserID"`
-	Exercise Exercise `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+	User        User         `json:"-" gorm:"foreignKey:UserID"`
+	Exercise    Exercise     `json:"exercise,omitempty" gorm:"foreignKey:ExerciseID"`
+	WorkoutSets []WorkoutSet `json:"workout_sets,omitempty" gorm:"foreignKey:WorkoutEntryID"`
 }
 
 func (WorkoutEntry) TableName() string {
 	return "workout_entries"
 }
+
+// BeforeCreate gives the entry a UUID unless the client that logged it chose one
+func (e *WorkoutEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.UUID == "" {
+		e.UUID = uuid.NewString()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkoutSession groups the entries logged in one workout. EndedAt is nil while the session
+// is in progress. Bodyweight, in kilograms, is what the session's entries are scored against
+// when it is set.
+type WorkoutSession struct {
+	ID         uint           `json:"id" gorm:"primarykey"`
+	UserID     uint           `json:"user_id" gorm:"index;not null"`
+	Title      string         `json:"title"`
+	Notes      string         `json:"notes"`
+	Location   string         `json:"location"`
+	Bodyweight *float64       `json:"bodyweight"`
+	StartedAt  time.Time      `json:"started_at" gorm:"not null;index"`
+	EndedAt    *time.Time     `json:"ended_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	User    User           `json:"-" gorm:"foreignKey:UserID"`
+	Entries []WorkoutEntry `json:"entries,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+func (WorkoutSession) TableName() string {
+	return "workout_sessions"
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkoutSet is one set of a workout entry. Type is warmup, working, drop or failure;
+// warmup sets are kept for the log but don't count towards scores or records. Effort is
+// recorded as either RPE or reps in reserve (RIR).
+type WorkoutSet struct {
+	ID             uint           `json:"id" gorm:"primarykey"`
+	WorkoutEntryID uint           `json:"workout_entry_id" gorm:"not null;uniqueIndex:idx_workout_sets_entry_index"`
+	SetIndex       int            `json:"set_index" gorm:"not null;uniqueIndex:idx_workout_sets_entry_index"`
+	Type           string         `json:"type" gorm:"not null;default:'working'"`
+	Weight         float64        `json:"weight" gorm:"not null;default:0"`
+	Reps           int            `json:"reps" gorm:"not null;default:0"`
+	Duration       int            `json:"duration" gorm:"not null;default:0"`
+	Distance       float64        `json:"distance" gorm:"not null;default:0"`
+	RPE            *float64       `json:"rpe"`
+	RIR            *int           `json:"rir"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	WorkoutEntry WorkoutEntry `json:"-" gorm:"foreignKey:WorkoutEntryID"`
+}
+
+func (WorkoutSet) TableName() string {
+	return "workout_sets"
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+)
+
+// Job is a unit of background work that runs on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+var jobs []Job
+
+// Register adds a job to be run once the scheduler is started
+func Register(job Job) {
+	jobs = append(jobs, job)
+}
+
+// Start runs every registered job once straight away and then on its interval.
+// Each job runs in its own goroutine so a slow job never delays the others.
+func Start() {
+	for _, job := range jobs {
+		go loop(job)
+	}
+	log.Printf("Scheduler started with %d job(s)", len(jobs))
+}
+
+func loop(job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		execute(job)
+		<-ticker.C
+	}
+}
+
+func execute(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	started := time.Now()
+	if err := job.Run(); err != nil {
+		log.Printf("Job %s failed: %v", job.Name, err)
+		return
+	}
+	log.Printf("Job %s completed in %s", job.Name, time.Since(started))
+}
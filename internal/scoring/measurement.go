@@ -0,0 +1,50 @@
+package scoring
+
+// Scorers for exercises that aren't measured by load. They are not registered, so they
+// can't be chosen as a strategy; the exercise's measurement type selects them instead.
+var (
+	TotalReps     Scorer = totalReps{}
+	TotalDuration Scorer = totalDuration{}
+	Speed         Scorer = speed{}
+)
+
+// totalReps is reps x sets, for exercises measured in reps alone
+type totalReps struct{}
+
+func (totalReps) Name() string        { return "total_reps" }
+func (totalReps) Version() int        { return 1 }
+func (totalReps) Description() string { return "Reps x sets" }
+func (totalReps) Cumulative()         {}
+func (totalReps) Score(in Input) float64 {
+	if in.Reps <= 0 || in.Sets <= 0 {
+		return 0
+	}
+	return float64(in.Reps * in.Sets)
+}
+
+// totalDuration is seconds x sets, for holds and other timed exercises
+type totalDuration struct{}
+
+func (totalDuration) Name() string        { return "total_duration" }
+func (totalDuration) Version() int        { return 1 }
+func (totalDuration) Description() string { return "Seconds x sets" }
+func (totalDuration) Cumulative()         {}
+func (totalDuration) Score(in Input) float64 {
+	if in.Duration <= 0 {
+		return 0
+	}
+	return float64(in.Duration * max(in.Sets, 1))
+}
+
+// speed is the average speed in km/h, for exercises measured by distance and time
+type speed struct{}
+
+func (speed) Name() string        { return "speed" }
+func (speed) Version() int        { return 1 }
+func (speed) Description() string { return "Average speed in km/h" }
+func (speed) Score(in Input) float64 {
+	if in.Distance <= 0 || in.Duration <= 0 {
+		return 0
+	}
+	return in.Distance / float64(in.Duration) * 3.6
+}
@@ -0,0 +1,47 @@
+package scoring
+
+import "math"
+
+// rpePercentages is the RPE chart flattened by effective reps, reps + (10 - RPE), in half
+// rep steps from 1 to 16: the percentage of a one-rep max that can be lifted for that many
+// reps at that RPE. A set of 5 at RPE 8 is 7 effective reps, or 81.1%.
+var rpePercentages = []float64{
+	100, 97.8, 95.5, 93.9, 92.2, 90.7, 89.2, 87.8, 86.3, 85.0, 83.7, 82.4, 81.1, 79.9, 78.6, 77.4,
+	76.2, 75.1, 73.9, 72.3, 70.7, 69.4, 68.0, 66.7, 65.3, 64.0, 62.6, 61.3, 59.9, 58.6, 57.4,
+}
+
+// The RPE chart covers 1 to 12 reps at RPE 6 to 10
+const (
+	MinChartRPE  = 6.0
+	MaxChartRPE  = 10.0
+	MaxChartReps = 12
+)
+
+// RPEPercentage returns the percentage of a one-rep max that can be lifted for reps at rpe,
+// interpolating between the chart's half-RPE steps. ok is false outside the chart.
+func RPEPercentage(reps int, rpe float64) (percentage float64, ok bool) {
+	if reps < 1 || reps > MaxChartReps || rpe < MinChartRPE || rpe > MaxChartRPE {
+		return 0, false
+	}
+
+	position := (float64(reps) + MaxChartRPE - rpe - 1) * 2
+	lower := int(math.Floor(position))
+	if lower >= len(rpePercentages)-1 {
+		return rpePercentages[len(rpePercentages)-1], true
+	}
+	fraction := position - float64(lower)
+	return rpePercentages[lower] + (rpePercentages[lower+1]-rpePercentages[lower])*fraction, true
+}
+
+// RPEOneRepMax estimates a one-rep max from a set and its RPE using the RPE chart, falling
+// back to the Epley formula when the set is outside the chart
+func RPEOneRepMax(weight float64, reps int, rpe float64) float64 {
+	if weight <= 0 || reps <= 0 {
+		return 0
+	}
+	percentage, ok := RPEPercentage(reps, rpe)
+	if !ok {
+		return EpleyOneRepMax(weight, reps)
+	}
+	return weight * 100 / percentage
+}
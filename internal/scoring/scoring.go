@@ -0,0 +1,100 @@
+// Package scoring defines the strategies used to turn a workout entry into a score.
+// Strategies register themselves by name; the name and version of the strategy that scored
+// an entry are stored with it so scores can be recomputed when a formula changes.
+package scoring
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Sexes accepted by the bodyweight-relative strategies
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// Default is the strategy used when neither the exercise nor the user has chosen one
+const Default = "volume"
+
+// ErrUnknownStrategy is returned when looking up a strategy that is not registered
+var ErrUnknownStrategy = errors.New("unknown scoring strategy")
+
+// Input is everything a strategy may use to score one entry. Weights are in kilograms,
+// Duration in seconds and Distance in metres. Sex is empty when the user hasn't set it;
+// bodyweight-relative strategies then use the male coefficients.
+type Input struct {
+	Weight     float64
+	Reps       int
+	Sets       int
+	Duration   int
+	Distance   float64
+	Bodyweight float64
+	Sex        string
+}
+
+// Scorer scores workout entries. Version must be bumped whenever the formula changes.
+type Scorer interface {
+	Name() string
+	Version() int
+	Description() string
+	Score(in Input) float64
+}
+
+// Cumulative is implemented by scorers whose score grows with every set performed, such as
+// volume. ScoreSets adds up their per-set scores and takes the best set for all others.
+type Cumulative interface {
+	Cumulative()
+}
+
+// ScoreSets scores each set on its own, as a single set, and combines the per-set scores
+func ScoreSets(s Scorer, sets []Input) float64 {
+	_, cumulative := s.(Cumulative)
+	var score float64
+	for _, set := range sets {
+		set.Sets = 1
+		setScore := s.Score(set)
+		if cumulative {
+			score += setScore
+		} else if setScore > score {
+			score = setScore
+		}
+	}
+	return score
+}
+
+var (
+	mu      sync.RWMutex
+	scorers = map[string]Scorer{}
+)
+
+// Register makes a strategy available under its name, replacing any previous registration
+func Register(s Scorer) {
+	mu.Lock()
+	defer mu.Unlock()
+	scorers[s.Name()] = s
+}
+
+// Get returns the registered strategy with the given name
+func Get(name string) (Scorer, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := scorers[name]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return s, nil
+}
+
+// All returns every registered strategy, sorted by name
+func All() []Scorer {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make([]Scorer, 0, len(scorers))
+	for _, s := range scorers {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
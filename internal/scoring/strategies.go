@@ -0,0 +1,163 @@
+package scoring
+
+import "math"
+
+// ReferenceBodyweight is the bodyweight relative volume is normalised to
+const ReferenceBodyweight = 75.0
+
+func init() {
+	Register(volume{})
+	Register(relativeVolume{})
+	Register(epley{})
+	Register(brzycki{})
+	Register(wilks{})
+	Register(dots{})
+	Register(ipfGL{})
+}
+
+// volume is the raw tonnage of the entry: weight x reps x sets
+type volume struct{}
+
+func (volume) Name() string        { return "volume" }
+func (volume) Version() int        { return 1 }
+func (volume) Description() string { return "Weight x reps x sets" }
+func (volume) Cumulative()         {}
+func (volume) Score(in Input) float64 {
+	if in.Weight <= 0 || in.Reps <= 0 || in.Sets <= 0 {
+		return 0
+	}
+	return in.Weight * float64(in.Reps) * float64(in.Sets)
+}
+
+// relativeVolume scales volume by ReferenceBodyweight / bodyweight
+type relativeVolume struct{}
+
+func (relativeVolume) Name() string { return "relative_volume" }
+func (relativeVolume) Version() int { return 1 }
+func (relativeVolume) Cumulative()  {}
+func (relativeVolume) Description() string {
+	return "Weight x reps x sets, normalised to a 75 kg bodyweight"
+}
+func (relativeVolume) Score(in Input) float64 {
+	score := volume{}.Score(in)
+	if in.Bodyweight <= 0 {
+		return score
+	}
+	return score * ReferenceBodyweight / in.Bodyweight
+}
+
+// epley estimates a one-rep max as weight x (1 + reps / 30)
+type epley struct{}
+
+func (epley) Name() string        { return "e1rm_epley" }
+func (epley) Version() int        { return 1 }
+func (epley) Description() string { return "Estimated one-rep max, Epley formula" }
+func (epley) Score(in Input) float64 {
+	return EpleyOneRepMax(in.Weight, in.Reps)
+}
+
+// brzycki estimates a one-rep max as weight x 36 / (37 - reps)
+type brzycki struct{}
+
+func (brzycki) Name() string        { return "e1rm_brzycki" }
+func (brzycki) Version() int        { return 1 }
+func (brzycki) Description() string { return "Estimated one-rep max, Brzycki formula" }
+func (brzycki) Score(in Input) float64 {
+	if in.Weight <= 0 || in.Reps <= 0 {
+		return 0
+	}
+	if in.Reps == 1 {
+		return in.Weight
+	}
+	// The formula breaks down at 37 reps; treat anything higher as 36
+	reps := math.Min(float64(in.Reps), 36)
+	return in.Weight * 36 / (37 - reps)
+}
+
+// EpleyOneRepMax estimates the weight that could be lifted for a single rep
+func EpleyOneRepMax(weight float64, reps int) float64 {
+	if weight <= 0 || reps <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// The bodyweight-relative strategies below apply their coefficient to the entry's Epley
+// estimated one-rep max, so any set can be compared against a lifter's true maximum.
+
+// wilks applies the original Wilks coefficient
+type wilks struct{}
+
+var (
+	wilksMale   = []float64{-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08}
+	wilksFemale = []float64{594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08}
+)
+
+func (wilks) Name() string        { return "wilks" }
+func (wilks) Version() int        { return 1 }
+func (wilks) Description() string { return "Wilks points of the estimated one-rep max" }
+func (wilks) Score(in Input) float64 {
+	coefficients, bw := wilksMale, clamp(in.Bodyweight, 40, 201.9)
+	if in.Sex == SexFemale {
+		coefficients, bw = wilksFemale, clamp(in.Bodyweight, 26.51, 154.53)
+	}
+	return relativeScore(in, 500, polynomial(coefficients, bw))
+}
+
+// dots applies the DOTS coefficient
+type dots struct{}
+
+var (
+	dotsMale   = []float64{-307.75076, 24.0900756, -0.1918759221, 0.0007391293, -0.000001093}
+	dotsFemale = []float64{-57.96288, 13.6175032, -0.1126655495, 0.0005158568, -0.0000010706}
+)
+
+func (dots) Name() string        { return "dots" }
+func (dots) Version() int        { return 1 }
+func (dots) Description() string { return "DOTS points of the estimated one-rep max" }
+func (dots) Score(in Input) float64 {
+	coefficients, bw := dotsMale, clamp(in.Bodyweight, 40, 210)
+	if in.Sex == SexFemale {
+		coefficients, bw = dotsFemale, clamp(in.Bodyweight, 40, 150)
+	}
+	return relativeScore(in, 500, polynomial(coefficients, bw))
+}
+
+// ipfGL applies the IPF GoodLift coefficient for classic powerlifting
+type ipfGL struct{}
+
+func (ipfGL) Name() string        { return "ipf_gl" }
+func (ipfGL) Version() int        { return 1 }
+func (ipfGL) Description() string { return "IPF GoodLift points of the estimated one-rep max" }
+func (ipfGL) Score(in Input) float64 {
+	a, b, c := 1199.72839, 1025.18162, 0.00921
+	if in.Sex == SexFemale {
+		a, b, c = 610.32796, 1045.59282, 0.03048
+	}
+	bw := math.Max(in.Bodyweight, 35)
+	return relativeScore(in, 100, a-b*math.Exp(-c*bw))
+}
+
+// relativeScore returns the entry's estimated one-rep max x numerator / denominator
+func relativeScore(in Input, numerator float64, denominator float64) float64 {
+	if in.Bodyweight <= 0 || denominator <= 0 {
+		return 0
+	}
+	return EpleyOneRepMax(in.Weight, in.Reps) * numerator / denominator
+}
+
+// polynomial evaluates coefficients[0] + coefficients[1]x + coefficients[2]x^2 + ...
+func polynomial(coefficients []float64, x float64) float64 {
+	var sum float64
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		sum = sum*x + coefficients[i]
+	}
+	return sum
+}
+
+func clamp(v float64, lo float64, hi float64) float64 {
+	return math.Max(lo, math.Min(v, hi))
+}
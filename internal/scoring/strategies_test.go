@@ -0,0 +1,140 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-4
+
+// coefficient is a bodyweight's published multiplier for one formula, to four decimals as
+// printed in the federation tables
+type coefficient struct {
+	sex        string
+	bodyweight float64
+	want       float64
+}
+
+// checkCoefficients scores a single 100kg rep at each bodyweight and compares the implied
+// multiplier with the published one
+func checkCoefficients(t *testing.T, name string, expected []coefficient) {
+	t.Helper()
+	scorer, err := Get(name)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", name, err)
+	}
+	for _, e := range expected {
+		got := scorer.Score(Input{Weight: 100, Reps: 1, Sets: 1, Bodyweight: e.bodyweight, Sex: e.sex}) / 100
+		if math.Abs(got-e.want) > tolerance {
+			t.Errorf("%s %s %.1fkg = %.6f, want %.4f", name, e.sex, e.bodyweight, got, e.want)
+		}
+	}
+}
+
+func TestWilks(t *testing.T) {
+	checkCoefficients(t, "wilks", []coefficient{
+		{SexMale, 60, 0.8529},
+		{SexMale, 75, 0.7126},
+		{SexMale, 90, 0.6384},
+		{SexMale, 100, 0.6086},
+		{SexMale, 110, 0.5885},
+		{SexFemale, 48, 1.3244},
+		{SexFemale, 52, 1.2466},
+		{SexFemale, 60, 1.1149},
+		{SexFemale, 72, 0.9760},
+	})
+}
+
+func TestDOTS(t *testing.T) {
+	checkCoefficients(t, "dots", []coefficient{
+		{SexMale, 75, 0.7174},
+		{SexMale, 90, 0.6466},
+		{SexMale, 100, 0.6155},
+		{SexMale, 110, 0.5923},
+		{SexFemale, 52, 1.2189},
+		{SexFemale, 60, 1.1085},
+		{SexFemale, 72, 0.9956},
+	})
+}
+
+func TestIPFGL(t *testing.T) {
+	checkCoefficients(t, "ipf_gl", []coefficient{
+		{SexMale, 83, 0.1384},
+		{SexMale, 93, 0.1308},
+		{SexMale, 105, 0.1235},
+		{SexFemale, 57, 0.2346},
+		{SexFemale, 63, 0.2188},
+		{SexFemale, 72, 0.2025},
+	})
+}
+
+func TestRelativeScoreEdges(t *testing.T) {
+	tests := []struct {
+		name   string
+		scorer string
+		in     Input
+		same   Input
+	}{
+		{
+			name:   "wilks clamps heavy male bodyweight",
+			scorer: "wilks",
+			in:     Input{Weight: 200, Reps: 1, Bodyweight: 250, Sex: SexMale},
+			same:   Input{Weight: 200, Reps: 1, Bodyweight: 201.9, Sex: SexMale},
+		},
+		{
+			name:   "wilks clamps light female bodyweight",
+			scorer: "wilks",
+			in:     Input{Weight: 100, Reps: 1, Bodyweight: 20, Sex: SexFemale},
+			same:   Input{Weight: 100, Reps: 1, Bodyweight: 26.51, Sex: SexFemale},
+		},
+		{
+			name:   "dots clamps heavy female bodyweight",
+			scorer: "dots",
+			in:     Input{Weight: 150, Reps: 1, Bodyweight: 180, Sex: SexFemale},
+			same:   Input{Weight: 150, Reps: 1, Bodyweight: 150, Sex: SexFemale},
+		},
+		{
+			name:   "ipf gl floors bodyweight",
+			scorer: "ipf_gl",
+			in:     Input{Weight: 100, Reps: 1, Bodyweight: 30, Sex: SexMale},
+			same:   Input{Weight: 100, Reps: 1, Bodyweight: 35, Sex: SexMale},
+		},
+		{
+			name:   "unknown sex scores as male",
+			scorer: "dots",
+			in:     Input{Weight: 180, Reps: 1, Bodyweight: 90},
+			same:   Input{Weight: 180, Reps: 1, Bodyweight: 90, Sex: SexMale},
+		},
+		{
+			name:   "reps are converted with Epley",
+			scorer: "wilks",
+			in:     Input{Weight: 150, Reps: 6, Bodyweight: 90, Sex: SexMale},
+			same:   Input{Weight: 180, Reps: 1, Bodyweight: 90, Sex: SexMale},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := Get(tt.scorer)
+			if err != nil {
+				t.Fatalf("Get(%q): %v", tt.scorer, err)
+			}
+			got, want := scorer.Score(tt.in), scorer.Score(tt.same)
+			if want == 0 || math.Abs(got-want) > tolerance {
+				t.Errorf("Score = %.6f, want %.6f", got, want)
+			}
+		})
+	}
+}
+
+func TestRelativeScoreWithoutBodyweight(t *testing.T) {
+	for _, name := range []string{"wilks", "dots", "ipf_gl"} {
+		scorer, err := Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", name, err)
+		}
+		if got := scorer.Score(Input{Weight: 100, Reps: 5, Sex: SexFemale}); got != 0 {
+			t.Errorf("%s without bodyweight = %v, want 0", name, got)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Alert rule types
+const (
+	AlertTypePriceAbove = "price_above"
+	AlertTypePriceBelow = "price_below"
+	AlertTypePctMove    = "pct_move"
+	AlertTypeNoEntry    = "no_entry"
+)
+
+var (
+	ErrInvalidAlertType      = errors.New("type must be one of price_above, price_below, pct_move or no_entry")
+	ErrInvalidAlertThreshold = errors.New("threshold must be greater than 0")
+	ErrInvalidAlertWindow    = errors.New("window_days must be greater than 0")
+)
+
+// ValidateAlertRule checks that a rule has the threshold and window its type needs
+func ValidateAlertRule(rule *models.AlertRule) error {
+	switch rule.Type {
+	case AlertTypePriceAbove, AlertTypePriceBelow:
+		if rule.Threshold <= 0 {
+			return ErrInvalidAlertThreshold
+		}
+	case AlertTypePctMove:
+		if rule.Threshold <= 0 {
+			return ErrInvalidAlertThreshold
+		}
+		if rule.WindowDays <= 0 {
+			return ErrInvalidAlertWindow
+		}
+	case AlertTypeNoEntry:
+		if rule.WindowDays <= 0 {
+			return ErrInvalidAlertWindow
+		}
+	default:
+		return ErrInvalidAlertType
+	}
+	return nil
+}
+
+// GetAlertRules returns the user's alert rules, optionally for one exercise
+func GetAlertRules(userID uint, exerciseID uint) ([]models.AlertRule, error) {
+	query := database.GetDB().Where("user_id = ?", userID)
+	if exerciseID != 0 {
+		query = query.Where("exercise_id = ?", exerciseID)
+	}
+
+	var rules []models.AlertRule
+	err := query.Preload("Exercise").Order("id ASC").Find(&rules).Error
+
+	return rules, err
+}
+
+// CreateAlertRule validates and stores a rule on one of the user's exercises
+func CreateAlertRule(rule *models.AlertRule) error {
+	if err := ValidateAlertRule(rule); err != nil {
+		return err
+	}
+
+	db := database.GetDB()
+	var exercise models.Exercise
+	if err := db.Where("id = ? AND user_id = ?", rule.ExerciseID, rule.UserID).First(&exercise).Error; err != nil {
+		return err
+	}
+
+	rule.Active = true
+	if err := db.Create(rule).Error; err != nil {
+		return err
+	}
+	rule.Exercise = exercise
+	return nil
+}
+
+// GetAlertRule returns one of the user's alert rules
+func GetAlertRule(userID uint, ruleID uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := database.GetDB().
+		Where("id = ? AND user_id = ?", ruleID, userID).
+		Preload("Exercise").
+		First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateAlertRule validates and saves changes to an existing rule
+func UpdateAlertRule(rule *models.AlertRule) error {
+	if err := ValidateAlertRule(rule); err != nil {
+		return err
+	}
+	return database.GetDB().Model(rule).Select("threshold", "window_days", "active").Updates(rule).Error
+}
+
+// DeleteAlertRule removes one of the user's alert rules
+func DeleteAlertRule(userID uint, ruleID uint) error {
+	result := database.GetDB().Where("id = ? AND user_id = ?", ruleID, userID).Delete(&models.AlertRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EvaluatePriceAlerts checks the active price rules on an exercise against a price change.
+// price_above and price_below fire when the price crosses their threshold; pct_move fires
+// when the price has moved at least Threshold percent over its window, at most once per window.
+func EvaluatePriceAlerts(exercise *models.Exercise, previousPrice float64, price float64) error {
+	var rules []models.AlertRule
+	err := database.GetDB().
+		Where("exercise_id = ? AND active = ? AND type IN ?", exercise.ID, true,
+			[]string{AlertTypePriceAbove, AlertTypePriceBelow, AlertTypePctMove}).
+		Find(&rules).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range rules {
+		rule := &rules[i]
+
+		switch rule.Type {
+		case AlertTypePriceAbove:
+			if previousPrice <= rule.Threshold && price > rule.Threshold {
+				err = triggerAlert(rule, exercise, fmt.Sprintf("%s crossed above %.2f", exercise.Ticker, rule.Threshold),
+					fmt.Sprintf("%s moved from %.2f to %.2f.", exercise.Ticker, previousPrice, price), now)
+			}
+		case AlertTypePriceBelow:
+			if previousPrice >= rule.Threshold && price < rule.Threshold {
+				err = triggerAlert(rule, exercise, fmt.Sprintf("%s crossed below %.2f", exercise.Ticker, rule.Threshold),
+					fmt.Sprintf("%s moved from %.2f to %.2f.", exercise.Ticker, previousPrice, price), now)
+			}
+		case AlertTypePctMove:
+			windowStart := now.AddDate(0, 0, -rule.WindowDays)
+			if rule.LastTriggeredAt != nil && rule.LastTriggeredAt.After(windowStart) {
+				continue
+			}
+			var basePrice float64
+			basePrice, err = GetAdjustedStockPriceAt(*exercise, windowStart)
+			if err != nil {
+				break
+			}
+			move := percentChange(basePrice, price)
+			if math.Abs(move) >= rule.Threshold {
+				err = triggerAlert(rule, exercise, fmt.Sprintf("%s moved %+.2f%% in %d days", exercise.Ticker, move, rule.WindowDays),
+					fmt.Sprintf("%s is at %.2f, from %.2f %d days ago.", exercise.Ticker, price, basePrice, rule.WindowDays), now)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EvaluateStaleAlerts checks every active no_entry rule, firing once for each stretch in which
+// its exercise has gone WindowDays without a workout entry. Rules on deleted exercises are skipped.
+func EvaluateStaleAlerts() error {
+	db := database.GetDB()
+
+	var rules []models.AlertRule
+	err := db.Where("type = ? AND active = ?", AlertTypeNoEntry, true).
+		Where("exercise_id IN (?)", db.Model(&models.Exercise{}).Select("id")).
+		Preload("Exercise").
+		Find(&rules).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range rules {
+		if err := evaluateStaleAlert(&rules[i], now); err != nil {
+			log.Printf("Failed to evaluate alert rule %d: %v", rules[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func evaluateStaleAlert(rule *models.AlertRule, now time.Time) error {
+	// A ticker that has never been logged has been stale since it was listed
+	lastActivity := rule.Exercise.CreatedAt
+	var latest models.WorkoutEntry
+	err := database.GetDB().
+		Where("exercise_id = ?", rule.ExerciseID).
+		Order("date DESC, id DESC").
+		First(&latest).Error
+	if err == nil {
+		lastActivity = latest.Date
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if now.Sub(lastActivity) < time.Duration(rule.WindowDays)*24*time.Hour {
+		return nil
+	}
+	if rule.LastTriggeredAt != nil && rule.LastTriggeredAt.After(lastActivity) {
+		return nil
+	}
+
+	return triggerAlert(rule, &rule.Exercise,
+		fmt.Sprintf("%s has gone %d days without an entry", rule.Exercise.Ticker, rule.WindowDays),
+		fmt.Sprintf("The last %s entry was on %s.", rule.Exercise.Ticker, lastActivity.Format("2006-01-02")), now)
+}
+
+// triggerAlert stores a notification for a rule and stamps when it last fired
+func triggerAlert(rule *models.AlertRule, exercise *models.Exercise, title string, message string, now time.Time) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		notification := models.Notification{
+			UserID:      rule.UserID,
+			AlertRuleID: &rule.ID,
+			ExerciseID:  &exercise.ID,
+			Title:       title,
+			Message:     message,
+		}
+		if err := tx.Create(&notification).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(rule).UpdateColumn("last_triggered_at", now).Error; err != nil {
+			return err
+		}
+		rule.LastTriggeredAt = &now
+		return nil
+	})
+}
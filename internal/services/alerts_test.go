@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+func TestEvaluateStaleAlertsSkipsDeletedExercises(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	live := createTestExercise(t, db, user.ID, "BP")
+	deleted := createTestExercise(t, db, user.ID, "SQ")
+
+	longAgo := time.Now().AddDate(0, 0, -30)
+	createTestEntry(t, db, live, longAgo, 100, 5)
+	createTestEntry(t, db, deleted, longAgo, 140, 5)
+	for _, exercise := range []models.Exercise{live, deleted} {
+		rule := models.AlertRule{UserID: user.ID, ExerciseID: exercise.ID, Type: AlertTypeNoEntry, WindowDays: 7, Active: true}
+		if err := db.Create(&rule).Error; err != nil {
+			t.Fatalf("create rule: %v", err)
+		}
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("delete exercise: %v", err)
+	}
+
+	if err := EvaluateStaleAlerts(); err != nil {
+		t.Fatalf("EvaluateStaleAlerts: %v", err)
+	}
+
+	var notifications []models.Notification
+	if err := db.Where("user_id = ?", user.ID).Find(&notifications).Error; err != nil {
+		t.Fatalf("load notifications: %v", err)
+	}
+	if len(notifications) != 1 || notifications[0].ExerciseID == nil || *notifications[0].ExerciseID != live.ID {
+		t.Fatalf("got %d notifications, want 1 for exercise %d", len(notifications), live.ID)
+	}
+	if want := "BP has gone 7 days without an entry"; notifications[0].Title != want {
+		t.Errorf("Title = %q, want %q", notifications[0].Title, want)
+	}
+}
@@ -0,0 +1,780 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// ArchiveSchemaVersion is bumped whenever the layout of an export archive changes
+	ArchiveSchemaVersion = 1
+	// ArchiveManifestName is the file in every archive that describes the rest
+	ArchiveManifestName = "manifest.json"
+	// ImportFormatArchive is the format of an import job restoring an export archive
+	ImportFormatArchive = "archive"
+
+	exportBatchSize = 500
+)
+
+// Formats the tables of an archive can be written in
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+var (
+	ErrInvalidExportFormat = errors.New("format must be json or csv")
+	ErrInvalidArchive      = errors.New("the archive is not a valid export")
+	ErrUnsupportedArchive  = fmt.Errorf("the archive's schema version is newer than %d", ArchiveSchemaVersion)
+
+	// errDryRun rolls back the transaction of a dry run restore
+	errDryRun = errors.New("dry run")
+)
+
+// Archive tables, named after the database tables they hold
+const (
+	archiveUsers              = "users"
+	archiveUserProfiles       = "user_profiles"
+	archiveBodyweightEntries  = "bodyweight_entries"
+	archiveExercisePRs        = "exercise_prs"
+	archiveExercises          = "exercises"
+	archiveWorkoutSessions    = "workout_sessions"
+	archiveWorkoutEntries     = "workout_entries"
+	archiveWorkoutSets        = "workout_sets"
+	archivePRHistory          = "pr_history"
+	archivePortfolioSnapshots = "portfolio_snapshots"
+)
+
+// ArchiveManifest describes an export archive
+type ArchiveManifest struct {
+	SchemaVersion int           `json:"schema_version"`
+	Format        string        `json:"format"`
+	ExportedAt    time.Time     `json:"exported_at"`
+	UserID        uint          `json:"user_id"`
+	Files         []ArchiveFile `json:"files"`
+}
+
+// ArchiveFile is one table of an archive and how many records it holds
+type ArchiveFile struct {
+	Name    string `json:"name"`
+	Table   string `json:"table"`
+	Records int    `json:"records"`
+}
+
+// archiveTables lists what an archive holds, in the order it is written and restored
+var archiveTables = []struct {
+	name   string
+	export func(db *gorm.DB, w *tableWriter) (int, error)
+}{
+	{archiveUsers, exportTable[models.User]},
+	{archiveUserProfiles, exportTable[models.UserProfile]},
+	{archiveBodyweightEntries, exportTable[models.BodyweightEntry]},
+	{archiveExercisePRs, exportTable[models.ExercisePR]},
+	{archiveExercises, exportTable[models.Exercise]},
+	{archiveWorkoutSessions, exportTable[models.WorkoutSession]},
+	{archiveWorkoutEntries, exportTable[models.WorkoutEntry]},
+	{archiveWorkoutSets, exportTable[models.WorkoutSet]},
+	{archivePRHistory, exportTable[models.PRHistory]},
+	{archivePortfolioSnapshots, exportTable[models.PortfolioSnapshot]},
+}
+
+// WriteExport streams a ZIP of everything the user has logged to w: one file per table in
+// format, JSON or CSV, plus a manifest with the schema version and record counts. The
+// archive can be uploaded to POST /imports to restore it into another account.
+func WriteExport(w io.Writer, userID uint, format string) error {
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		return ErrInvalidExportFormat
+	}
+
+	db := database.GetDB()
+	manifest := ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Format:        format,
+		ExportedAt:    time.Now().UTC(),
+		UserID:        userID,
+	}
+
+	archive := zip.NewWriter(w)
+	for _, table := range archiveTables {
+		name := table.name + "." + format
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+
+		records, err := table.export(archiveQuery(db, table.name, userID), newTableWriter(file, format))
+		if err != nil {
+			return fmt.Errorf("exporting %s: %w", table.name, err)
+		}
+		manifest.Files = append(manifest.Files, ArchiveFile{Name: name, Table: table.name, Records: records})
+	}
+
+	file, err := archive.Create(ArchiveManifestName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// archiveQuery scopes a table to the user's records. Entries, their sets and PR history are
+// left out along with the exercise they belong to once it is deleted, so that every entry
+// in an archive has its exercise.
+func archiveQuery(db *gorm.DB, table string, userID uint) *gorm.DB {
+	exercises := db.Model(&models.Exercise{}).Select("id").Where("user_id = ?", userID)
+	switch table {
+	case archiveUsers:
+		return db.Where("id = ?", userID)
+	case archiveWorkoutEntries, archivePRHistory:
+		return db.Where("user_id = ? AND exercise_id IN (?)", userID, exercises)
+	case archiveWorkoutSets:
+		entries := db.Model(&models.WorkoutEntry{}).Select("id").Where("user_id = ? AND exercise_id IN (?)", userID, exercises)
+		return db.Where("workout_entry_id IN (?)", entries)
+	}
+	return db.Where("user_id = ?", userID)
+}
+
+// exportTable writes the records of a query in batches, returning how many there were
+func exportTable[T any](query *gorm.DB, w *tableWriter) (int, error) {
+	if err := w.begin(reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+		return 0, err
+	}
+
+	var batch []T
+	err := query.Order("id ASC").FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := w.write(reflect.ValueOf(batch[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+	return w.count, w.end()
+}
+
+// archiveColumn is a field of a model written to archives, named by its JSON tag
+type archiveColumn struct {
+	name  string
+	index int
+}
+
+// archiveColumns returns the fields of a model that hold data rather than relationships:
+// strings, numbers, booleans and times, or pointers to them, that have a JSON name
+func archiveColumns(t reflect.Type) []archiveColumn {
+	var columns []archiveColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !isArchiveScalar(field.Type) {
+			continue
+		}
+		columns = append(columns, archiveColumn{name: name, index: i})
+	}
+	return columns
+}
+
+func isArchiveScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// tableWriter writes records as a JSON array of objects or as CSV with a header row
+type tableWriter struct {
+	out     io.Writer
+	format  string
+	csv     *csv.Writer
+	columns []archiveColumn
+	count   int
+}
+
+func newTableWriter(out io.Writer, format string) *tableWriter {
+	return &tableWriter{out: out, format: format}
+}
+
+func (w *tableWriter) begin(t reflect.Type) error {
+	w.columns = archiveColumns(t)
+	if w.format == ExportFormatCSV {
+		w.csv = csv.NewWriter(w.out)
+		header := make([]string, len(w.columns))
+		for i, column := range w.columns {
+			header[i] = column.name
+		}
+		return w.csv.Write(header)
+	}
+	_, err := io.WriteString(w.out, "[")
+	return err
+}
+
+func (w *tableWriter) write(record reflect.Value) error {
+	w.count++
+	if w.format == ExportFormatCSV {
+		row := make([]string, len(w.columns))
+		for i, column := range w.columns {
+			row[i] = formatArchiveValue(record.Field(column.index))
+		}
+		return w.csv.Write(row)
+	}
+
+	var buf bytes.Buffer
+	if w.count > 1 {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\n  {")
+	for i, column := range w.columns {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		value, err := json.Marshal(record.Field(column.index).Interface())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%q:%s", column.name, value)
+	}
+	buf.WriteString("}")
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+func (w *tableWriter) end() error {
+	if w.format == ExportFormatCSV {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	_, err := io.WriteString(w.out, "\n]\n")
+	return err
+}
+
+func formatArchiveValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return ""
+}
+
+func parseArchiveValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		if value == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if value == "" && v.Kind() != reflect.String {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
+
+// archive is the content of an uploaded export
+type archive struct {
+	manifest          ArchiveManifest
+	users             []models.User
+	userProfiles      []models.UserProfile
+	bodyweightEntries []models.BodyweightEntry
+	exercisePRs       []models.ExercisePR
+	exercises         []models.Exercise
+	workoutSessions   []models.WorkoutSession
+	workoutEntries    []models.WorkoutEntry
+	workoutSets       []models.WorkoutSet
+	portfolioSnaps    []models.PortfolioSnapshot
+}
+
+// IsArchive reports whether an upload is a ZIP rather than a CSV
+func IsArchive(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// readArchive reads the manifest and tables of an export archive
+func readArchive(data []byte) (*archive, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	a := &archive{}
+	manifestFile, ok := files[ArchiveManifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, ArchiveManifestName)
+	}
+	if err := readArchiveFile(manifestFile, func(r io.Reader) error { return json.NewDecoder(r).Decode(&a.manifest) }); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if a.manifest.SchemaVersion < 1 {
+		return nil, fmt.Errorf("%w: the manifest has no schema version", ErrInvalidArchive)
+	}
+	if a.manifest.SchemaVersion > ArchiveSchemaVersion {
+		return nil, ErrUnsupportedArchive
+	}
+	if a.manifest.Format != ExportFormatJSON && a.manifest.Format != ExportFormatCSV {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, ErrInvalidExportFormat)
+	}
+
+	targets := map[string]interface{}{
+		archiveUsers:              &a.users,
+		archiveUserProfiles:       &a.userProfiles,
+		archiveBodyweightEntries:  &a.bodyweightEntries,
+		archiveExercisePRs:        &a.exercisePRs,
+		archiveExercises:          &a.exercises,
+		archiveWorkoutSessions:    &a.workoutSessions,
+		archiveWorkoutEntries:     &a.workoutEntries,
+		archiveWorkoutSets:        &a.workoutSets,
+		archivePortfolioSnapshots: &a.portfolioSnaps,
+	}
+	for _, entry := range a.manifest.Files {
+		target, ok := targets[entry.Table]
+		if !ok {
+			// Derived tables such as pr_history are rebuilt rather than restored
+			continue
+		}
+		file, ok := files[entry.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, entry.Name)
+		}
+		err := readArchiveFile(file, func(r io.Reader) error {
+			if a.manifest.Format == ExportFormatCSV {
+				return decodeCSVTable(r, target)
+			}
+			return json.NewDecoder(r).Decode(target)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, entry.Name, err)
+		}
+	}
+	return a, nil
+}
+
+func readArchiveFile(file *zip.File, read func(io.Reader) error) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return read(r)
+}
+
+// decodeCSVTable reads a CSV table into target, a pointer to a slice of models, matching
+// header names to the models' JSON names
+func decodeCSVTable(r io.Reader, target interface{}) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	slice := reflect.ValueOf(target).Elem()
+	fields := map[string]int{}
+	for _, column := range archiveColumns(slice.Type().Elem()) {
+		fields[column.name] = column.index
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		record := reflect.New(slice.Type().Elem()).Elem()
+		for i, name := range header {
+			index, ok := fields[name]
+			if !ok || i >= len(row) {
+				continue
+			}
+			if err := parseArchiveValue(record.Field(index), row[i]); err != nil {
+				return fmt.Errorf("line %d, %s: %v", line, name, err)
+			}
+		}
+		slice.Set(reflect.Append(slice, record))
+	}
+}
+
+// recordCount is how many records the archive holds that a restore reads
+func (a *archive) recordCount() int {
+	return len(a.users) + len(a.userProfiles) + len(a.bodyweightEntries) + len(a.exercisePRs) +
+		len(a.exercises) + len(a.workoutSessions) + len(a.workoutEntries) + len(a.workoutSets) +
+		len(a.portfolioSnaps)
+}
+
+// restoreArchive restores an export archive into the job's user. Everything is restored in
+// one transaction, which a dry run rolls back. Records the user already has are skipped, so
+// restoring the same archive twice changes nothing. PR history is rebuilt from the restored
+// entries rather than copied, and exercises that gained entries are repriced.
+func restoreArchive(job *models.ImportJob, a *archive) {
+	now := time.Now()
+	job.Status = ImportStatusRunning
+	job.StartedAt = &now
+	database.GetDB().Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": now})
+
+	var result *archiveRestore
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = applyArchive(tx, job.UserID, a, job.DryRun)
+		if err == nil && job.DryRun {
+			return errDryRun
+		}
+		return err
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	if err == nil && !job.DryRun {
+		for _, exerciseID := range result.touched {
+			if _, err = RepriceExercise(exerciseID, PriceReasonImported); err != nil {
+				break
+			}
+		}
+	}
+
+	if result != nil {
+		job.Restored = result.restored
+		job.Exercises = result.exercises
+		job.EntryCount = result.restored[archiveWorkoutEntries]
+		job.SetCount = result.restored[archiveWorkoutSets]
+		job.DuplicateCount = result.duplicates
+		job.Errors = []models.ImportJobError{}
+		for _, exercise := range result.exercises {
+			if exercise.Created {
+				job.CreatedExercises++
+			}
+		}
+	}
+	finishImport(job, err)
+}
+
+// archiveRestore is what restoring an archive did
+type archiveRestore struct {
+	restored   map[string]int
+	exercises  []models.ImportJobExercise
+	duplicates int
+	touched    []uint
+}
+
+func applyArchive(tx *gorm.DB, userID uint, a *archive, dryRun bool) (*archiveRestore, error) {
+	result := &archiveRestore{restored: map[string]int{}}
+
+	// The account keeps its own email and password; only a missing name is filled in
+	if len(a.users) > 0 && a.users[0].Name != "" {
+		update := tx.Model(&models.User{}).Where("id = ? AND name = ?", userID, "").Update("name", a.users[0].Name)
+		if update.Error != nil {
+			return nil, update.Error
+		}
+		result.restored[archiveUsers] = int(update.RowsAffected)
+	}
+
+	if len(a.userProfiles) > 0 {
+		restored := a.userProfiles[0]
+		var profile models.UserProfile
+		err := tx.Where("user_id = ?", userID).First(&profile).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		profile.UserID = userID
+		profile.Timezone = restored.Timezone
+		profile.Sex = restored.Sex
+		profile.ScoringStrategy = restored.ScoringStrategy
+		profile.PlateIncrement = restored.PlateIncrement
+		if err := tx.Save(&profile).Error; err != nil {
+			return nil, err
+		}
+		result.restored[archiveUserProfiles] = 1
+	}
+
+	var bodyweights []models.BodyweightEntry
+	if err := tx.Where("user_id = ?", userID).Find(&bodyweights).Error; err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, entry := range bodyweights {
+		seen[fmt.Sprintf("%d|%g", entry.RecordedAt.Unix(), entry.Weight)] = true
+	}
+	for _, entry := range a.bodyweightEntries {
+		key := fmt.Sprintf("%d|%g", entry.RecordedAt.Unix(), entry.Weight)
+		if seen[key] {
+			result.duplicates++
+			continue
+		}
+		seen[key] = true
+		entry.ID, entry.UserID, entry.UUID = 0, userID, ""
+		if err := tx.Omit("User").Create(&entry).Error; err != nil {
+			return nil, err
+		}
+		result.restored[archiveBodyweightEntries]++
+	}
+
+	var prs []models.ExercisePR
+	if err := tx.Where("user_id = ?", userID).Find(&prs).Error; err != nil {
+		return nil, err
+	}
+	seen = map[string]bool{}
+	for _, pr := range prs {
+		seen[fmt.Sprintf("%s|%d|%g|%d", pr.ExerciseName, pr.RecordedAt.Unix(), pr.Weight, pr.Reps)] = true
+	}
+	for _, pr := range a.exercisePRs {
+		key := fmt.Sprintf("%s|%d|%g|%d", pr.ExerciseName, pr.RecordedAt.Unix(), pr.Weight, pr.Reps)
+		if seen[key] {
+			result.duplicates++
+			continue
+		}
+		seen[key] = true
+		pr.ID, pr.UserID = 0, userID
+		if err := tx.Omit("User").Create(&pr).Error; err != nil {
+			return nil, err
+		}
+		result.restored[archiveExercisePRs]++
+	}
+
+	// Exercises are matched to the user's own by ticker
+	var existing []models.Exercise
+	if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byTicker := map[string]models.Exercise{}
+	for _, exercise := range existing {
+		byTicker[exercise.Ticker] = exercise
+	}
+	exerciseIDs := map[uint]uint{}
+	reports := map[uint]int{}
+	for _, exercise := range a.exercises {
+		oldID := exercise.ID
+		report := models.ImportJobExercise{Name: exercise.Name, Ticker: exercise.Ticker, MeasurementType: MeasurementType(exercise)}
+		if match, ok := byTicker[exercise.Ticker]; ok {
+			exerciseIDs[oldID] = match.ID
+			report.ExerciseID = match.ID
+			report.MeasurementType = MeasurementType(match)
+		} else {
+			if exercise.CatalogExerciseID != nil {
+				var count int64
+				if err := tx.Model(&models.CatalogExercise{}).Where("id = ?", *exercise.CatalogExerciseID).Count(&count).Error; err != nil {
+					return nil, err
+				}
+				if count == 0 {
+					exercise.CatalogExerciseID = nil
+				}
+			}
+			exercise.ID, exercise.UserID, exercise.UUID = 0, userID, ""
+			if err := tx.Omit("User", "WorkoutEntries", "CatalogExercise").Create(&exercise).Error; err != nil {
+				return nil, err
+			}
+			exerciseIDs[oldID] = exercise.ID
+			byTicker[exercise.Ticker] = exercise
+			report.Created = true
+			if !dryRun {
+				report.ExerciseID = exercise.ID
+			}
+			result.restored[archiveExercises]++
+		}
+		reports[oldID] = len(result.exercises)
+		result.exercises = append(result.exercises, report)
+	}
+
+	// Sessions are matched to the user's own by start time
+	var sessions []models.WorkoutSession
+	if err := tx.Where("user_id = ?", userID).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	byStart := map[int64]uint{}
+	for _, session := range sessions {
+		byStart[session.StartedAt.UnixNano()] = session.ID
+	}
+	sessionIDs := map[uint]uint{}
+	for _, session := range a.workoutSessions {
+		oldID := session.ID
+		if id, ok := byStart[session.StartedAt.UnixNano()]; ok {
+			sessionIDs[oldID] = id
+			result.duplicates++
+			continue
+		}
+		session.ID, session.UserID = 0, userID
+		if err := tx.Omit("User", "Entries").Create(&session).Error; err != nil {
+			return nil, err
+		}
+		sessionIDs[oldID] = session.ID
+		byStart[session.StartedAt.UnixNano()] = session.ID
+		result.restored[archiveWorkoutSessions]++
+	}
+
+	setsByEntry := map[uint][]models.WorkoutSet{}
+	for _, set := range a.workoutSets {
+		setsByEntry[set.WorkoutEntryID] = append(setsByEntry[set.WorkoutEntryID], set)
+	}
+
+	entryKeys, err := existingEntryKeys(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	touched := map[uint]bool{}
+	var entries []models.WorkoutEntry
+	for _, entry := range a.workoutEntries {
+		exerciseID, ok := exerciseIDs[entry.ExerciseID]
+		if !ok {
+			return nil, fmt.Errorf("%w: entry %d belongs to an exercise that isn't in the archive", ErrInvalidArchive, entry.ID)
+		}
+		key := entryKey(exerciseID, entry.Date, EntryPerformance(entry))
+		if entryKeys[key] {
+			result.duplicates++
+			continue
+		}
+		entryKeys[key] = true
+
+		sets := setsByEntry[entry.ID]
+		for i := range sets {
+			sets[i].ID, sets[i].WorkoutEntryID = 0, 0
+		}
+		if entry.SessionID != nil {
+			if sessionID, ok := sessionIDs[*entry.SessionID]; ok {
+				entry.SessionID = &sessionID
+			} else {
+				entry.SessionID = nil
+			}
+		}
+		result.exercises[reports[entry.ExerciseID]].EntryCount++
+		entry.ID, entry.UserID, entry.ExerciseID, entry.UUID = 0, userID, exerciseID, ""
+		entry.IsPR = false
+		entry.WorkoutSets = sets
+		entries = append(entries, entry)
+		touched[exerciseID] = true
+		result.restored[archiveWorkoutSets] += len(sets)
+	}
+	if len(entries) > 0 {
+		if err := tx.Omit("User", "Exercise").CreateInBatches(&entries, importBatchSize).Error; err != nil {
+			return nil, err
+		}
+	}
+	result.restored[archiveWorkoutEntries] = len(entries)
+
+	for _, exercise := range a.exercises {
+		exerciseID := exerciseIDs[exercise.ID]
+		if !touched[exerciseID] {
+			continue
+		}
+		delete(touched, exerciseID)
+		if err := rebuildPRHistory(tx, exerciseID); err != nil {
+			return nil, err
+		}
+		result.touched = append(result.touched, exerciseID)
+	}
+
+	var snapshots []models.PortfolioSnapshot
+	if err := tx.Where("user_id = ?", userID).Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	snapshotDates := map[string]bool{}
+	for _, snapshot := range snapshots {
+		snapshotDates[snapshot.Date.UTC().Format("2006-01-02")] = true
+	}
+	for _, snapshot := range a.portfolioSnaps {
+		date := snapshot.Date.UTC().Format("2006-01-02")
+		if snapshotDates[date] {
+			result.duplicates++
+			continue
+		}
+		snapshotDates[date] = true
+		snapshot.ID, snapshot.UserID = 0, userID
+		if err := tx.Omit("User").Create(&snapshot).Error; err != nil {
+			return nil, err
+		}
+		result.restored[archivePortfolioSnapshots]++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+func TestExportRestoreRoundTrip(t *testing.T) {
+	for _, format := range []string{ExportFormatJSON, ExportFormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			db := setupTestDB(t)
+			owner := createTestUser(t, db)
+			bench := createTestExercise(t, db, owner.ID, "BP")
+			squat := createTestExercise(t, db, owner.ID, "SQ")
+
+			day := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+			createTestEntry(t, db, bench, day, 80, 5)
+			createTestEntry(t, db, bench, day.AddDate(0, 0, 2), 85, 5)
+			createTestEntry(t, db, squat, day, 120, 5)
+
+			// Entries of a deleted exercise stay in the database but not in the export
+			if err := db.Delete(&squat).Error; err != nil {
+				t.Fatalf("delete exercise: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := WriteExport(&buf, owner.ID, format); err != nil {
+				t.Fatalf("WriteExport: %v", err)
+			}
+			a, err := readArchive(buf.Bytes())
+			if err != nil {
+				t.Fatalf("readArchive: %v", err)
+			}
+			if len(a.exercises) != 1 || len(a.workoutEntries) != 2 || len(a.workoutSets) != 2 {
+				t.Fatalf("archive holds %d exercises, %d entries and %d sets, want 1, 2 and 2",
+					len(a.exercises), len(a.workoutEntries), len(a.workoutSets))
+			}
+
+			restorer := createTestUser(t, db)
+			job := &models.ImportJob{UserID: restorer.ID, Format: ImportFormatArchive, Status: ImportStatusPending}
+			if err := db.Create(job).Error; err != nil {
+				t.Fatalf("create job: %v", err)
+			}
+			restoreArchive(job, a)
+			if job.Status != ImportStatusCompleted {
+				t.Fatalf("restore %s: %s", job.Status, job.Failure)
+			}
+
+			var entries []models.WorkoutEntry
+			if err := preloadSets(db).Where("user_id = ?", restorer.ID).Order("date ASC").Find(&entries).Error; err != nil {
+				t.Fatalf("load entries: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("restored %d entries, want 2", len(entries))
+			}
+			for i, want := range []float64{80, 85} {
+				if entries[i].Weight != want || !entries[i].Date.Equal(day.AddDate(0, 0, 2*i)) || len(entries[i].WorkoutSets) != 1 {
+					t.Errorf("entry %d = %.0fkg on %s with %d sets, want %.0fkg on %s with 1 set", i,
+						entries[i].Weight, entries[i].Date.Format("2006-01-02"), len(entries[i].WorkoutSets),
+						want, day.AddDate(0, 0, 2*i).Format("2006-01-02"))
+				}
+			}
+
+			// Restoring the same archive again changes nothing
+			again := &models.ImportJob{UserID: restorer.ID, Format: ImportFormatArchive, Status: ImportStatusPending}
+			if err := db.Create(again).Error; err != nil {
+				t.Fatalf("create job: %v", err)
+			}
+			restoreArchive(again, a)
+			if again.Status != ImportStatusCompleted || again.EntryCount != 0 {
+				t.Errorf("second restore %s with %d entries, want completed with 0", again.Status, again.EntryCount)
+			}
+		})
+	}
+}
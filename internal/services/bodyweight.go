@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBodyweight is assumed for users who haven't logged their bodyweight
+const DefaultBodyweight = 70.0
+
+// GetEntryBodyweight returns the bodyweight in kilograms an entry is scored against: the
+// bodyweight logged with its session, if any, else the user's bodyweight on the entry's date
+func GetEntryBodyweight(userID uint, sessionID *uint, at time.Time) float64 {
+	if sessionID != nil {
+		var session models.WorkoutSession
+		err := database.GetDB().Where("id = ? AND user_id = ?", *sessionID, userID).First(&session).Error
+		if err == nil && session.Bodyweight != nil && *session.Bodyweight > 0 {
+			return *session.Bodyweight
+		}
+	}
+	return GetUserBodyweightAt(userID, at)
+}
+
+// GetUserBodyweightAt returns the user's bodyweight in kilograms at the given time,
+// interpolated linearly between the bodyweight entries either side of it. Before the first
+// entry or after the last, the nearest entry is used; with no entries DefaultBodyweight is.
+func GetUserBodyweightAt(userID uint, at time.Time) float64 {
+	db := database.GetDB()
+	at = at.UTC()
+
+	var before, after models.BodyweightEntry
+	errBefore := db.Where("user_id = ? AND recorded_at <= ?", userID, at).
+		Order("recorded_at DESC, id DESC").
+		First(&before).Error
+	errAfter := db.Where("user_id = ? AND recorded_at > ?", userID, at).
+		Order("recorded_at ASC, id ASC").
+		First(&after).Error
+
+	switch {
+	case errBefore != nil && errAfter != nil:
+		return DefaultBodyweight
+	case errBefore != nil:
+		return bodyweightKg(after)
+	case errAfter != nil:
+		return bodyweightKg(before)
+	}
+
+	span := after.RecordedAt.Sub(before.RecordedAt)
+	if span <= 0 {
+		return bodyweightKg(before)
+	}
+	progress := float64(at.Sub(before.RecordedAt)) / float64(span)
+	return bodyweightKg(before) + (bodyweightKg(after)-bodyweightKg(before))*progress
+}
+
+// BodyweightInfluence returns the window of time whose interpolated bodyweight depends on a
+// bodyweight entry at the given time: from the user's previous entry to their next one,
+// ignoring the entry itself. A zero to means the window is open-ended.
+func BodyweightInfluence(userID uint, at time.Time, entryID uint) (from time.Time, to time.Time, err error) {
+	db := database.GetDB()
+	at = at.UTC()
+
+	var previous models.BodyweightEntry
+	err = db.Where("user_id = ? AND id <> ? AND recorded_at < ?", userID, entryID, at).
+		Order("recorded_at DESC, id DESC").
+		First(&previous).Error
+	if err == nil {
+		from = previous.RecordedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return from, to, err
+	}
+
+	var next models.BodyweightEntry
+	err = db.Where("user_id = ? AND id <> ? AND recorded_at > ?", userID, entryID, at).
+		Order("recorded_at ASC, id ASC").
+		First(&next).Error
+	if err == nil {
+		to = next.RecordedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return from, to, err
+	}
+
+	return from, to, nil
+}
+
+// bodyweightWindowExercises returns the exercises with an entry dated inside a window whose
+// interpolated bodyweight a bodyweight entry affects. A zero to is open-ended.
+func bodyweightWindowExercises(userID uint, from time.Time, to time.Time) ([]uint, error) {
+	query := database.GetDB().Model(&models.WorkoutEntry{}).
+		Where("user_id = ? AND date > ?", userID, from.UTC())
+	if !to.IsZero() {
+		query = query.Where("date < ?", to.UTC())
+	}
+
+	var exerciseIDs []uint
+	err := query.Distinct().Pluck("exercise_id", &exerciseIDs).Error
+	return exerciseIDs, err
+}
+
+// StartBodyweightRescore starts a rescore job for the exercises with entries whose
+// interpolated bodyweight depends on a bodyweight entry at any of the given times, after it
+// was added, edited or deleted. It returns nil when no entries do.
+func StartBodyweightRescore(userID uint, entryID uint, times ...time.Time) (*models.RescoreJob, error) {
+	var exerciseIDs []uint
+	seen := map[uint]bool{}
+	for _, at := range times {
+		from, to, err := BodyweightInfluence(userID, at, entryID)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := bodyweightWindowExercises(userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				exerciseIDs = append(exerciseIDs, id)
+			}
+		}
+	}
+	return StartRescoreJob(userID, RescoreReasonBodyweight, exerciseIDs)
+}
+
+func bodyweightKg(entry models.BodyweightEntry) float64 {
+	if entry.Unit == "lb" || entry.Unit == "lbs" {
+		return entry.Weight * 0.453592
+	}
+	return entry.Weight
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+)
+
+func TestSessionBodyweightScoresItsEntries(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "BP")
+	if err := db.Model(&exercise).UpdateColumn("scoring_strategy", "wilks").Error; err != nil {
+		t.Fatalf("set strategy: %v", err)
+	}
+
+	day := time.Date(2026, 8, 3, 18, 0, 0, 0, time.UTC)
+	logged := models.BodyweightEntry{UserID: user.ID, Weight: 90, Unit: "kg", RecordedAt: day.AddDate(0, 0, -1)}
+	if err := db.Create(&logged).Error; err != nil {
+		t.Fatalf("create bodyweight: %v", err)
+	}
+	weighed := 82.5
+	withBodyweight := models.WorkoutSession{UserID: user.ID, StartedAt: day, Bodyweight: &weighed}
+	without := models.WorkoutSession{UserID: user.ID, StartedAt: day.AddDate(0, 0, 1)}
+	for _, session := range []*models.WorkoutSession{&withBodyweight, &without} {
+		if err := db.Create(session).Error; err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		sessionID *uint
+		want      float64
+	}{
+		{"session with bodyweight", &withBodyweight.ID, 82.5},
+		{"session without bodyweight", &without.ID, 90},
+		{"no session", nil, 90},
+	}
+	sets := []models.WorkoutSet{{Type: SetTypeWorking, Weight: 100, Reps: 1}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetEntryBodyweight(user.ID, tt.sessionID, day); got != tt.want {
+				t.Errorf("GetEntryBodyweight = %v, want %v", got, tt.want)
+			}
+			score, err := ScoreEntry(exercise, sets, day, tt.sessionID)
+			if err != nil {
+				t.Fatalf("ScoreEntry: %v", err)
+			}
+			wilks, _ := scoring.Get("wilks")
+			if want := wilks.Score(scoring.Input{Weight: 100, Reps: 1, Sets: 1, Bodyweight: tt.want}); score.Value != want {
+				t.Errorf("ScoreEntry = %.4f, want %.4f", score.Value, want)
+			}
+		})
+	}
+}
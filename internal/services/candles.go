@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+)
+
+// Supported candle intervals
+const (
+	CandleIntervalDay   = "day"
+	CandleIntervalWeek  = "week"
+	CandleIntervalMonth = "month"
+)
+
+// ErrInvalidCandleInterval is returned for an interval other than day, week or month
+var ErrInvalidCandleInterval = errors.New("interval must be one of day, week or month")
+
+// Candle is an OHLC bar of entry scores for one interval.
+// Volume is the total reps of the working sets logged during the interval.
+type Candle struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume int       `json:"volume"`
+}
+
+// GetCandles aggregates an exercise's workout entry scores into OHLC bars between from and to.
+// Intervals without entries carry the previous close forward with zero volume; intervals
+// before the exercise's first entry are omitted. Scores are not prices, so splits leave the
+// bars unchanged.
+func GetCandles(exerciseID uint, interval string, from time.Time, to time.Time) ([]Candle, error) {
+	if interval != CandleIntervalDay && interval != CandleIntervalWeek && interval != CandleIntervalMonth {
+		return nil, ErrInvalidCandleInterval
+	}
+
+	db := database.GetDB()
+	start := truncateToInterval(from, interval)
+
+	// Seed the carried-forward close with the last entry before the range
+	var previous models.WorkoutEntry
+	hasPrevious := true
+	err := db.Where("exercise_id = ? AND date < ?", exerciseID, start).
+		Order("date DESC, id DESC").
+		First(&previous).Error
+	if err != nil {
+		hasPrevious = false
+	}
+
+	var entries []models.WorkoutEntry
+	err = preloadSets(db).Where("exercise_id = ? AND date >= ? AND date <= ?", exerciseID, start, to).
+		Order("date ASC, id ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	candles := []Candle{}
+	lastClose := previous.Score
+	i := 0
+	for bucket := start; !bucket.After(to); bucket = nextInterval(bucket, interval) {
+		end := nextInterval(bucket, interval)
+
+		var candle *Candle
+		for i < len(entries) && entries[i].Date.Before(end) {
+			entry := entries[i]
+			if candle == nil {
+				candle = &Candle{Time: bucket, Open: entry.Score, High: entry.Score, Low: entry.Score}
+			}
+			if entry.Score > candle.High {
+				candle.High = entry.Score
+			}
+			if entry.Score < candle.Low {
+				candle.Low = entry.Score
+			}
+			candle.Close = entry.Score
+			for _, set := range WorkingSets(EntrySets(entry)) {
+				candle.Volume += set.Reps
+			}
+			i++
+		}
+
+		if candle == nil {
+			if !hasPrevious {
+				continue
+			}
+			candle = &Candle{Time: bucket, Open: lastClose, High: lastClose, Low: lastClose, Close: lastClose}
+		}
+
+		candles = append(candles, *candle)
+		lastClose = candle.Close
+		hasPrevious = true
+	}
+
+	return candles, nil
+}
+
+// truncateToInterval returns the start of the interval containing t, in UTC.
+// Weeks start on Monday.
+func truncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch interval {
+	case CandleIntervalWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case CandleIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case CandleIntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case CandleIntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
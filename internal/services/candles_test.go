@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+func TestCandleVolumeCountsWorkingReps(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "BP")
+
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	sets := []models.WorkoutSet{
+		{SetIndex: 1, Type: SetTypeWarmup, Weight: 40, Reps: 10},
+		{SetIndex: 2, Type: SetTypeWorking, Weight: 100, Reps: 5},
+		{SetIndex: 3, Type: SetTypeWorking, Weight: 90, Reps: 8},
+		{SetIndex: 4, Type: SetTypeWorking, Weight: 80, Reps: 10},
+	}
+	summary := SummarizeSets(MeasurementWeightReps, sets)
+	entry := models.WorkoutEntry{
+		UserID: user.ID, ExerciseID: exercise.ID, Date: day, Score: 100,
+		Weight: summary.Weight, Reps: summary.Reps, Sets: summary.Sets, WorkoutSets: sets,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+	createTestEntry(t, db, exercise, day, 60, 12)
+
+	candles, err := GetCandles(exercise.ID, CandleIntervalDay, day, day)
+	if err != nil {
+		t.Fatalf("GetCandles: %v", err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("got %d candles, want 1", len(candles))
+	}
+	if got, want := candles[0].Volume, 5+8+10+12; got != want {
+		t.Errorf("Volume = %d, want %d", got, want)
+	}
+}
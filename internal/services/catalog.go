@@ -0,0 +1,47 @@
+package services
+
+import (
+	"strings"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// SearchCatalog returns up to limit catalog exercises whose ticker, name, equipment or muscles
+// match q, optionally within one category. An exact ticker match is listed first.
+func SearchCatalog(q string, category string, limit int) ([]models.CatalogExercise, error) {
+	query := database.GetDB().Model(&models.CatalogExercise{})
+
+	q = strings.TrimSpace(q)
+	if q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where(
+			"LOWER(ticker) LIKE ? OR LOWER(name) LIKE ? OR LOWER(equipment) LIKE ? OR LOWER(primary_muscles) LIKE ? OR LOWER(secondary_muscles) LIKE ?",
+			pattern, pattern, pattern, pattern, pattern,
+		).Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN UPPER(ticker) = ? THEN 0 ELSE 1 END, name ASC",
+			Vars: []interface{}{strings.ToUpper(q)},
+		}})
+	} else {
+		query = query.Order("name ASC")
+	}
+	if category != "" {
+		query = query.Where("LOWER(category) = ?", strings.ToLower(category))
+	}
+
+	var exercises []models.CatalogExercise
+	err := query.Limit(limit).Find(&exercises).Error
+
+	return exercises, err
+}
+
+// GetCatalogExercise returns a single catalog exercise
+func GetCatalogExercise(id uint) (*models.CatalogExercise, error) {
+	var exercise models.CatalogExercise
+	if err := database.GetDB().First(&exercise, id).Error; err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
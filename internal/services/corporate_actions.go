@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Corporate action types and triggers
+const (
+	CorporateActionSplit        = "split"
+	CorporateActionReverseSplit = "reverse_split"
+
+	CorporateActionTriggerAutomatic = "automatic"
+	CorporateActionTriggerManual    = "manual"
+)
+
+const (
+	// SplitUpperThreshold is the price at which a ticker is automatically split
+	SplitUpperThreshold = 1000.0
+	// ReverseSplitLowerThreshold is the price below which a ticker is automatically reverse split
+	ReverseSplitLowerThreshold = 10.0
+	// AutoSplitRatio is the ratio used for automatic splits, inverted for reverse splits
+	AutoSplitRatio = 10.0
+)
+
+var (
+	// ErrInvalidSplitRatio is returned for a split ratio that is not positive or is exactly 1
+	ErrInvalidSplitRatio = errors.New("split ratio must be greater than 0 and not equal to 1")
+	// ErrSplitBelowMinPrice is returned for a split that would price the stock below MinStockPrice
+	ErrSplitBelowMinPrice = fmt.Errorf("split would price the stock below %.2f", MinStockPrice)
+)
+
+// ApplySplit splits (ratio > 1) or reverse splits (ratio < 1) an exercise's stock, dividing
+// its price and price alert thresholds by the ratio, multiplying shares held in it by the
+// ratio and recording the action and the price change. A split that would take the price
+// below MinStockPrice is refused, as the holdings would then lose value.
+func ApplySplit(exercise *models.Exercise, ratio float64, trigger string) (*models.CorporateAction, error) {
+	if ratio <= 0 || ratio == 1 {
+		return nil, ErrInvalidSplitRatio
+	}
+	priceAfter := roundPrice(exercise.StockPrice / ratio)
+	if priceAfter < MinStockPrice {
+		return nil, ErrSplitBelowMinPrice
+	}
+
+	actionType := CorporateActionSplit
+	if ratio < 1 {
+		actionType = CorporateActionReverseSplit
+	}
+
+	now := time.Now()
+	action := models.CorporateAction{
+		UserID:      exercise.UserID,
+		ExerciseID:  exercise.ID,
+		Type:        actionType,
+		Ratio:       ratio,
+		PriceBefore: exercise.StockPrice,
+		PriceAfter:  priceAfter,
+		Trigger:     trigger,
+		EffectiveAt: now,
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&action).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(exercise).UpdateColumn("stock_price", action.PriceAfter).Error; err != nil {
+			return err
+		}
+		exercise.StockPrice = action.PriceAfter
+
+		err := tx.Model(&models.Holding{}).
+			Where("exercise_id = ?", exercise.ID).
+			Updates(map[string]interface{}{
+				"shares":       gorm.Expr("shares * ?", ratio),
+				"average_cost": gorm.Expr("average_cost / ?", ratio),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		// Keep price alert thresholds on the same scale as the split price
+		err = tx.Model(&models.AlertRule{}).
+			Where("exercise_id = ? AND type IN ?", exercise.ID, []string{AlertTypePriceAbove, AlertTypePriceBelow}).
+			UpdateColumn("threshold", gorm.Expr("threshold / ?", ratio)).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PriceHistory{
+			UserID:        exercise.UserID,
+			ExerciseID:    exercise.ID,
+			Price:         action.PriceAfter,
+			PreviousPrice: action.PriceBefore,
+			Reason:        actionType,
+			RecordedAt:    now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &action, nil
+}
+
+// ApplyAutomaticSplit splits an exercise whose price has grown past SplitUpperThreshold or
+// reverse splits one that has collapsed below ReverseSplitLowerThreshold. It returns nil
+// when the price is within range.
+func ApplyAutomaticSplit(exercise *models.Exercise) (*models.CorporateAction, error) {
+	switch {
+	case exercise.StockPrice >= SplitUpperThreshold:
+		return ApplySplit(exercise, AutoSplitRatio, CorporateActionTriggerAutomatic)
+	case exercise.StockPrice < ReverseSplitLowerThreshold:
+		return ApplySplit(exercise, 1/AutoSplitRatio, CorporateActionTriggerAutomatic)
+	default:
+		return nil, nil
+	}
+}
+
+// GetCorporateActions returns an exercise's splits and reverse splits, oldest first
+func GetCorporateActions(userID uint, exerciseID uint) ([]models.CorporateAction, error) {
+	var actions []models.CorporateAction
+	err := database.GetDB().
+		Where("user_id = ? AND exercise_id = ?", userID, exerciseID).
+		Order("effective_at ASC, id ASC").
+		Find(&actions).Error
+
+	return actions, err
+}
+
+// GetSplitFactor returns the combined ratio of an exercise's corporate actions that took
+// effect after the first time and at or before the second. Dividing a price from before
+// that window by the factor puts it on the same scale as prices after it.
+func GetSplitFactor(exerciseID uint, after time.Time, until time.Time) (float64, error) {
+	var ratios []float64
+	err := database.GetDB().Model(&models.CorporateAction{}).
+		Where("exercise_id = ? AND effective_at > ? AND effective_at <= ?", exerciseID, after.UTC(), until.UTC()).
+		Pluck("ratio", &ratios).Error
+	if err != nil {
+		return 0, err
+	}
+
+	factor := 1.0
+	for _, ratio := range ratios {
+		factor *= ratio
+	}
+	return factor, nil
+}
+
+// GetAdjustedStockPriceAt returns an exercise's price at the given time, restated on
+// today's split-adjusted scale
+func GetAdjustedStockPriceAt(exercise models.Exercise, at time.Time) (float64, error) {
+	price, err := GetStockPriceAt(exercise, at)
+	if err != nil {
+		return 0, err
+	}
+	factor, err := GetSplitFactor(exercise.ID, at, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return roundPrice(price / factor), nil
+}
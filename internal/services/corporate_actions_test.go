@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+func TestApplySplitKeepsSeriesContinuous(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "BP")
+
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		createTestEntry(t, db, exercise, day.AddDate(0, 0, i), 100+float64(i)*10, 5)
+		if _, err := RepriceExercise(exercise.ID, PriceReasonEntryCreated); err != nil {
+			t.Fatalf("RepriceExercise: %v", err)
+		}
+	}
+	to := day.AddDate(0, 0, 9)
+
+	before, err := GetCandles(exercise.ID, CandleIntervalDay, day, to)
+	if err != nil {
+		t.Fatalf("GetCandles: %v", err)
+	}
+	if err := db.First(&exercise, exercise.ID).Error; err != nil {
+		t.Fatalf("reload exercise: %v", err)
+	}
+	priceBefore := exercise.StockPrice
+
+	if _, err := ApplySplit(&exercise, 10, CorporateActionTriggerManual); err != nil {
+		t.Fatalf("ApplySplit: %v", err)
+	}
+
+	// Scores aren't prices, so the candles are the same bars they were before the split
+	after, err := GetCandles(exercise.ID, CandleIntervalDay, day, to)
+	if err != nil {
+		t.Fatalf("GetCandles: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("got %d candles after the split, want %d", len(after), len(before))
+	}
+	for i := range after {
+		if after[i] != before[i] {
+			t.Errorf("candle %d = %+v after the split, want %+v", i, after[i], before[i])
+		}
+	}
+
+	raw, err := GetPriceHistory(user.ID, exercise.ID, false)
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	last := raw[len(raw)-1]
+	if last.Reason != CorporateActionSplit || last.PreviousPrice != priceBefore || last.Price != roundPrice(priceBefore/10) {
+		t.Errorf("split row = %s %.2f -> %.2f, want %s %.2f -> %.2f",
+			last.Reason, last.PreviousPrice, last.Price, CorporateActionSplit, priceBefore, roundPrice(priceBefore/10))
+	}
+
+	// Adjusted, every change chains into the next and the series ends at today's price
+	adjusted, err := GetPriceHistory(user.ID, exercise.ID, true)
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	if len(adjusted) != len(raw)-1 {
+		t.Fatalf("got %d adjusted changes, want %d", len(adjusted), len(raw)-1)
+	}
+	for i := 1; i < len(adjusted); i++ {
+		if adjusted[i].PreviousPrice != adjusted[i-1].Price {
+			t.Errorf("adjusted change %d starts at %.2f, want %.2f", i, adjusted[i].PreviousPrice, adjusted[i-1].Price)
+		}
+	}
+	if got := adjusted[len(adjusted)-1].Price; got != exercise.StockPrice {
+		t.Errorf("adjusted series ends at %.2f, want %.2f", got, exercise.StockPrice)
+	}
+}
+
+func TestApplySplitRefusesPriceBelowFloor(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "BP")
+	if err := db.Model(&exercise).UpdateColumn("stock_price", 5).Error; err != nil {
+		t.Fatalf("set price: %v", err)
+	}
+	exercise.StockPrice = 5
+
+	if _, err := ApplySplit(&exercise, 10, CorporateActionTriggerManual); !errors.Is(err, ErrSplitBelowMinPrice) {
+		t.Fatalf("ApplySplit = %v, want %v", err, ErrSplitBelowMinPrice)
+	}
+	var actions int64
+	db.Model(&models.CorporateAction{}).Where("exercise_id = ?", exercise.ID).Count(&actions)
+	if actions != 0 || exercise.StockPrice != 5 {
+		t.Errorf("refused split left %d actions and price %.2f, want 0 and 5.00", actions, exercise.StockPrice)
+	}
+
+	// A split landing exactly on the floor keeps price x shares
+	action, err := ApplySplit(&exercise, 5, CorporateActionTriggerManual)
+	if err != nil {
+		t.Fatalf("ApplySplit: %v", err)
+	}
+	if action.PriceAfter != MinStockPrice || action.PriceAfter*action.Ratio != action.PriceBefore {
+		t.Errorf("split %.2f -> %.2f at ratio %g, want %.2f -> %.2f", action.PriceBefore, action.PriceAfter, action.Ratio, 5.0, MinStockPrice)
+	}
+}
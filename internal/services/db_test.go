@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB points the database package at a fresh, migrated in-memory SQLite database
+// for the length of the test
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared&_busy_timeout=5000", name)), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+
+	previous, output := database.DB, log.Writer()
+	database.DB = db
+	log.SetOutput(io.Discard)
+	database.AutoMigrate()
+	database.RunMigrations()
+
+	t.Cleanup(func() {
+		log.SetOutput(output)
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// createTestUser saves a user with a unique email
+func createTestUser(t *testing.T, db *gorm.DB) models.User {
+	t.Helper()
+	var count int64
+	db.Model(&models.User{}).Unscoped().Count(&count)
+	user := models.User{Email: fmt.Sprintf("user%d@example.com", count+1), Password: "x", Name: "Test"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createTestExercise saves a weight_reps exercise of the user's with the given ticker
+func createTestExercise(t *testing.T, db *gorm.DB, userID uint, ticker string) models.Exercise {
+	t.Helper()
+	exercise := models.Exercise{
+		UserID:          userID,
+		Ticker:          ticker,
+		Name:            ticker,
+		Category:        "strength",
+		StockPrice:      100,
+		MeasurementType: MeasurementWeightReps,
+	}
+	if err := db.Create(&exercise).Error; err != nil {
+		t.Fatalf("create exercise: %v", err)
+	}
+	return exercise
+}
+
+// createTestEntry saves a single working set of the exercise on the given date, scored as
+// the entry handler scores it
+func createTestEntry(t *testing.T, db *gorm.DB, exercise models.Exercise, date time.Time, weight float64, reps int) models.WorkoutEntry {
+	t.Helper()
+	sets := []models.WorkoutSet{{SetIndex: 1, Type: SetTypeWorking, Weight: weight, Reps: reps}}
+	score, err := ScoreEntry(exercise, sets, date, nil)
+	if err != nil {
+		t.Fatalf("score entry: %v", err)
+	}
+	summary := SummarizeSets(MeasurementType(exercise), sets)
+	entry := models.WorkoutEntry{
+		UserID:          exercise.UserID,
+		ExerciseID:      exercise.ID,
+		Weight:          summary.Weight,
+		Reps:            summary.Reps,
+		Sets:            summary.Sets,
+		Date:            date,
+		Score:           score.Value,
+		ScoringStrategy: score.Strategy,
+		ScoringVersion:  score.Version,
+		WorkoutSets:     sets,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+	return entry
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DividendMinWeeklyEntries is how many entries a ticker needs in a week to pay a dividend
+	DividendMinWeeklyEntries = 2
+	// DividendRate is the share of the stock price paid out for each qualifying week
+	DividendRate = 0.01
+)
+
+// PayDueDividends pays consistency dividends for every user's completed weeks
+func PayDueDividends() error {
+	db := database.GetDB()
+
+	var userIDs []uint
+	if err := db.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		if _, err := PayUserDividends(userID, now); err != nil {
+			log.Printf("Failed to pay dividends for user %d: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// PayUserDividends pays dividends for each of the user's local Monday-to-Sunday weeks that
+// has ended since their last dividend, or since they signed up. Weeks already paid are skipped,
+// so running it repeatedly never pays the same ticker twice for a week.
+func PayUserDividends(userID uint, now time.Time) ([]models.Dividend, error) {
+	db := database.GetDB()
+	loc := GetUserLocation(userID)
+
+	var last *time.Time
+	var latest models.Dividend
+	err := db.Where("user_id = ?", userID).Order("period_start DESC").First(&latest).Error
+	if err == nil {
+		// Re-check the latest paid week in case other tickers qualified after it was processed
+		start := latest.PeriodStart.AddDate(0, 0, -1)
+		last = &start
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	days, err := dueDays(userID, now, loc, last)
+	if err != nil {
+		return nil, err
+	}
+
+	paid := []models.Dividend{}
+	for _, day := range days {
+		// A week is due once its Sunday has ended
+		if day.Weekday() != time.Sunday {
+			continue
+		}
+		dividends, err := PayWeeklyDividends(userID, day.AddDate(0, 0, -6), loc)
+		if err != nil {
+			return paid, err
+		}
+		paid = append(paid, dividends...)
+	}
+
+	return paid, nil
+}
+
+// PayWeeklyDividends pays a dividend on each of the user's tickers trained at least
+// DividendMinWeeklyEntries times in the week starting on weekStart (a Monday calendar date)
+func PayWeeklyDividends(userID uint, weekStart time.Time, loc *time.Location) ([]models.Dividend, error) {
+	db := database.GetDB()
+	from := EndOfDay(weekStart.AddDate(0, 0, -1), loc)
+	to := EndOfDay(weekStart.AddDate(0, 0, 6), loc)
+
+	type entryCount struct {
+		ExerciseID uint
+		Count      int
+	}
+	var counts []entryCount
+	err := db.Model(&models.WorkoutEntry{}).
+		Select("exercise_id, COUNT(*) as count").
+		Where("user_id = ? AND date >= ? AND date < ?", userID, from, to).
+		Group("exercise_id").
+		Having("COUNT(*) >= ?", DividendMinWeeklyEntries).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	paid := []models.Dividend{}
+	for _, count := range counts {
+		var exercise models.Exercise
+		if err := db.Unscoped().First(&exercise, count.ExerciseID).Error; err != nil {
+			return paid, err
+		}
+
+		dividend, err := payDividend(exercise, weekStart, to, count.Count)
+		if err != nil {
+			return paid, err
+		}
+		if dividend != nil {
+			paid = append(paid, *dividend)
+		}
+	}
+
+	return paid, nil
+}
+
+// GetDividends returns the dividends paid to the user, newest first
+func GetDividends(userID uint) ([]models.Dividend, error) {
+	var dividends []models.Dividend
+	err := database.GetDB().
+		Where("user_id = ?", userID).
+		Preload("Exercise").
+		Order("period_start DESC, id DESC").
+		Find(&dividends).Error
+
+	return dividends, err
+}
+
+// GetDividendIncome returns the total of all dividends paid to the user
+func GetDividendIncome(userID uint) (float64, error) {
+	var total float64
+	err := database.GetDB().Model(&models.Dividend{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+
+	return roundPrice(total), err
+}
+
+// payDividend credits one ticker's dividend for a week, returning nil if it was already paid
+func payDividend(exercise models.Exercise, weekStart time.Time, weekEnd time.Time, entryCount int) (*models.Dividend, error) {
+	db := database.GetDB()
+
+	var existing int64
+	err := db.Model(&models.Dividend{}).
+		Where("exercise_id = ? AND period_start = ?", exercise.ID, weekStart).
+		Count(&existing).Error
+	if err != nil || existing > 0 {
+		return nil, err
+	}
+
+	price, err := GetStockPriceAt(exercise, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	amount := roundPrice(price * DividendRate)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	dividend := models.Dividend{
+		UserID:      exercise.UserID,
+		ExerciseID:  exercise.ID,
+		PeriodStart: weekStart,
+		PeriodEnd:   weekStart.AddDate(0, 0, 6),
+		EntryCount:  entryCount,
+		StockPrice:  price,
+		Amount:      amount,
+		PaidAt:      time.Now(),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		exerciseID := exercise.ID
+		ledgerEntry := models.CashLedgerEntry{
+			UserID:      exercise.UserID,
+			Type:        LedgerTypeDividend,
+			Amount:      amount,
+			ExerciseID:  &exerciseID,
+			Description: fmt.Sprintf("%s dividend for week of %s", exercise.Ticker, weekStart.Format("2006-01-02")),
+			OccurredAt:  dividend.PaidAt,
+		}
+		if err := PostLedgerEntry(tx, &ledgerEntry); err != nil {
+			return err
+		}
+
+		dividend.LedgerEntryID = ledgerEntry.ID
+		return tx.Create(&dividend).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dividend, nil
+}
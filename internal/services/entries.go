@@ -0,0 +1,256 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Sort orders for listing entries; a leading - sorts descending
+const (
+	EntrySortDate      = "date"
+	EntrySortDateDesc  = "-date"
+	EntrySortScore     = "score"
+	EntrySortScoreDesc = "-score"
+)
+
+var (
+	ErrInvalidEntrySort = errors.New("sort must be one of date, -date, score or -score")
+	ErrInvalidCursor    = errors.New("cursor is invalid")
+)
+
+// EntryFilter narrows the entries returned by ListEntries. Zero fields don't filter; To is
+// exclusive.
+type EntryFilter struct {
+	ExerciseID uint
+	SessionID  uint
+	Category   string
+	From       time.Time
+	To         time.Time
+	IsPR       *bool
+}
+
+// entryCursor marks the last entry of a page: its sort value and its ID as a tie-break
+type entryCursor struct {
+	Date  time.Time `json:"d,omitempty"`
+	Score float64   `json:"s,omitempty"`
+	ID    uint      `json:"id"`
+}
+
+// ListEntries returns a page of the user's entries, with their sets and exercise, and the
+// cursor of the next page, which is empty on the last page
+func ListEntries(userID uint, filter EntryFilter, sort string, cursor string, limit int) ([]models.WorkoutEntry, string, error) {
+	db := database.GetDB()
+
+	column, descending := "date", true
+	switch sort {
+	case "", EntrySortDateDesc:
+	case EntrySortDate:
+		descending = false
+	case EntrySortScore:
+		column, descending = "score", false
+	case EntrySortScoreDesc:
+		column = "score"
+	default:
+		return nil, "", ErrInvalidEntrySort
+	}
+
+	query := db.Where("user_id = ?", userID)
+	if filter.ExerciseID != 0 {
+		query = query.Where("exercise_id = ?", filter.ExerciseID)
+	}
+	if filter.SessionID != 0 {
+		query = query.Where("session_id = ?", filter.SessionID)
+	}
+	if filter.Category != "" {
+		query = query.Where("exercise_id IN (?)",
+			db.Model(&models.Exercise{}).Select("id").Where("user_id = ? AND category = ?", userID, filter.Category))
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("date >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("date < ?", filter.To.UTC())
+	}
+	if filter.IsPR != nil {
+		query = query.Where("is_pr = ?", *filter.IsPR)
+	}
+
+	if cursor != "" {
+		after, err := decodeEntryCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		var value interface{} = after.Date.UTC()
+		if column == "score" {
+			value = after.Score
+		}
+		op := ">"
+		if descending {
+			op = "<"
+		}
+		query = query.Where("("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))", value, value, after.ID)
+	}
+
+	direction := " ASC"
+	if descending {
+		direction = " DESC"
+	}
+
+	var entries []models.WorkoutEntry
+	err := preloadSets(query).
+		Preload("Exercise").
+		Order(column + direction).
+		Order("id" + direction).
+		Limit(limit + 1).
+		Find(&entries).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		next = encodeEntryCursor(entryCursor{Date: last.Date, Score: last.Score, ID: last.ID})
+	}
+	return entries, next, nil
+}
+
+// GetEntry returns one of the user's entries with its sets and exercise
+func GetEntry(userID uint, entryID uint) (*models.WorkoutEntry, error) {
+	var entry models.WorkoutEntry
+	err := preloadSets(database.GetDB()).
+		Preload("Exercise").
+		Where("id = ? AND user_id = ?", entryID, userID).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetEntryRecords returns the PR history rows an entry set
+func GetEntryRecords(entryID uint) ([]models.PRHistory, error) {
+	var records []models.PRHistory
+	err := database.GetDB().
+		Where("workout_entry_id = ?", entryID).
+		Order("id ASC").
+		Find(&records).Error
+	return records, err
+}
+
+// UpdateEntry saves changes to an entry. When sets is not nil it replaces the entry's sets.
+// The entry is summarised and rescored from its sets, the exercise's PR history is rebuilt
+// so records and IsPR flags stay correct, and the exercise is repriced.
+func UpdateEntry(entry *models.WorkoutEntry, sets []models.WorkoutSet) error {
+	db := database.GetDB()
+
+	var exercise models.Exercise
+	if err := db.First(&exercise, entry.ExerciseID).Error; err != nil {
+		return err
+	}
+
+	replaceSets := sets != nil
+	if !replaceSets {
+		sets = EntrySets(*entry)
+	}
+
+	measurementType := MeasurementType(exercise)
+	summary := SummarizeSets(measurementType, sets)
+	score, err := ScoreEntry(exercise, sets, entry.Date, entry.SessionID)
+	if err != nil {
+		return err
+	}
+	entry.Weight = summary.Weight
+	entry.Reps = summary.Reps
+	entry.Sets = summary.Sets
+	entry.Duration = summary.Duration
+	entry.Distance = summary.Distance
+	entry.Score = score.Value
+	entry.ScoringStrategy = score.Strategy
+	entry.ScoringVersion = score.Version
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if replaceSets {
+			if err := tx.Unscoped().Where("workout_entry_id = ?", entry.ID).Delete(&models.WorkoutSet{}).Error; err != nil {
+				return err
+			}
+			for i := range sets {
+				sets[i].ID = 0
+				sets[i].WorkoutEntryID = entry.ID
+			}
+			if err := tx.Create(&sets).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("User", "Exercise", "WorkoutSets").Save(entry).Error; err != nil {
+			return err
+		}
+		return rebuildPRHistory(tx, entry.ExerciseID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := RepriceExercise(entry.ExerciseID, PriceReasonEntryUpdated); err != nil {
+		return err
+	}
+
+	updated, err := GetEntry(entry.UserID, entry.ID)
+	if err != nil {
+		return err
+	}
+	*entry = *updated
+	return nil
+}
+
+// DeleteEntry removes one of the user's entries with its sets, rebuilds the exercise's PR
+// history without it and reprices the exercise
+func DeleteEntry(userID uint, entryID uint) error {
+	db := database.GetDB()
+
+	var entry models.WorkoutEntry
+	if err := db.Where("id = ? AND user_id = ?", entryID, userID).First(&entry).Error; err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workout_entry_id = ?", entry.ID).Delete(&models.WorkoutSet{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entry).Error; err != nil {
+			return err
+		}
+		return rebuildPRHistory(tx, entry.ExerciseID)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = RepriceExercise(entry.ExerciseID, PriceReasonEntryDeleted)
+	return err
+}
+
+func encodeEntryCursor(cursor entryCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeEntryCursor(value string) (entryCursor, error) {
+	var cursor entryCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
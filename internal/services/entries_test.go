@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// entryPRFlags returns the IsPR flag of each of the exercise's entries by ID
+func entryPRFlags(t *testing.T, db *gorm.DB, exerciseID uint) map[uint]bool {
+	t.Helper()
+	var entries []models.WorkoutEntry
+	if err := db.Where("exercise_id = ?", exerciseID).Find(&entries).Error; err != nil {
+		t.Fatalf("load entries: %v", err)
+	}
+	flags := map[uint]bool{}
+	for _, entry := range entries {
+		flags[entry.ID] = entry.IsPR
+	}
+	return flags
+}
+
+func TestUpdateAndDeleteEntryRebuildPRs(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "OHP")
+
+	day := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	first := logTestEntry(t, db, exercise, day, 80, 5)
+	second := logTestEntry(t, db, exercise, day.AddDate(0, 0, 2), 100, 5)
+	third := logTestEntry(t, db, exercise, day.AddDate(0, 0, 4), 90, 5)
+	if flags := entryPRFlags(t, db, exercise.ID); flags[first.ID] || !flags[second.ID] || flags[third.ID] {
+		t.Fatalf("is_pr = %v before editing, want only entry %d", flags, second.ID)
+	}
+
+	// Lightening the record entry hands the record to the one after it
+	entry, err := GetEntry(user.ID, second.ID)
+	if err != nil {
+		t.Fatalf("GetEntry: %v", err)
+	}
+	if err := UpdateEntry(entry, []models.WorkoutSet{{SetIndex: 1, Type: SetTypeWorking, Weight: 70, Reps: 5}}); err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+	if entry.Weight != 70 || entry.Score >= third.Score {
+		t.Errorf("updated entry weighs %v and scores %v, want 70 and below %v", entry.Weight, entry.Score, third.Score)
+	}
+	if flags := entryPRFlags(t, db, exercise.ID); flags[first.ID] || flags[second.ID] || !flags[third.ID] {
+		t.Errorf("is_pr = %v after the update, want only entry %d", flags, third.ID)
+	}
+	records, err := GetEntryRecords(second.ID)
+	if err != nil {
+		t.Fatalf("GetEntryRecords: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("updated entry holds %d PR history rows, want 0", len(records))
+	}
+
+	// Deleting the new record entry leaves none
+	if err := DeleteEntry(user.ID, third.ID); err != nil {
+		t.Fatalf("DeleteEntry: %v", err)
+	}
+	if flags := entryPRFlags(t, db, exercise.ID); len(flags) != 2 || flags[first.ID] || flags[second.ID] {
+		t.Errorf("is_pr = %v after the delete, want two entries and no PRs", flags)
+	}
+	var history int64
+	db.Model(&models.PRHistory{}).Where("workout_entry_id = ?", third.ID).Count(&history)
+	if history != 0 {
+		t.Errorf("deleted entry still holds %d PR history rows, want 0", history)
+	}
+}
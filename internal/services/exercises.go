@@ -0,0 +1,20 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+)
+
+var tickerPattern = regexp.MustCompile("^[A-Z0-9]+$")
+
+// ValidateTicker validates the ticker symbol format
+func ValidateTicker(ticker string) error {
+	if len(ticker) < 2 || len(ticker) > 10 {
+		return errors.New("ticker symbol must be between 2 and 10 characters")
+	}
+	// Ticker must be uppercase alphanumeric only
+	if !tickerPattern.MatchString(ticker) {
+		return errors.New("ticker symbol must contain only uppercase letters and numbers")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// IdempotencyKeyTTL is how long a response is kept for replaying
+	IdempotencyKeyTTL = 24 * time.Hour
+	// MaxIdempotencyKeyLength is the longest Idempotency-Key accepted
+	MaxIdempotencyKeyLength = 255
+	// IdempotencyClaimLease is how long a key can be claimed without a response before it
+	// is assumed its request died, with the process, and the key can be claimed again
+	IdempotencyClaimLease = time.Minute
+)
+
+var (
+	ErrIdempotencyKeyTooLong  = errors.New("Idempotency-Key must be at most 255 characters")
+	ErrIdempotencyKeyMismatch = errors.New("Idempotency-Key was already used for a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this Idempotency-Key is still being processed")
+)
+
+// HashRequest fingerprints a request so a reused key can be told apart from a retry
+func HashRequest(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// BeginIdempotentRequest claims a key for a request. It returns nil when the request is new
+// and should be handled, or the stored key when it was already answered and the response
+// should be replayed. A key still being handled or used for a different request is an error;
+// a claim older than IdempotencyClaimLease that was never answered is taken over.
+func BeginIdempotentRequest(userID uint, key string, method string, path string, requestHash string) (*models.IdempotencyKey, error) {
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, ErrIdempotencyKeyTooLong
+	}
+
+	db := database.GetDB()
+	now := time.Now()
+
+	var existing models.IdempotencyKey
+	err := db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error
+	switch {
+	case err == nil && existing.ExpiresAt.After(now) && existing.StatusCode != 0:
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		return &existing, nil
+	case err == nil && existing.ExpiresAt.After(now) && existing.CreatedAt.After(now.Add(-IdempotencyClaimLease)):
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		return nil, ErrIdempotencyKeyInFlight
+	case err == nil:
+		// An expired key, or a claim whose request never finished, is free to be used again
+		result := db.Unscoped().Where("id = ? AND status_code = ?", existing.ID, existing.StatusCode).Delete(&models.IdempotencyKey{})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	claim := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(IdempotencyKeyTTL),
+	}
+	if err := db.Create(&claim).Error; err != nil {
+		// Another request claimed the key first
+		if db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error == nil {
+			if existing.RequestHash != requestHash {
+				return nil, ErrIdempotencyKeyMismatch
+			}
+			return nil, ErrIdempotencyKeyInFlight
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+// CompleteIdempotentRequest stores the response to a claimed key for replaying
+func CompleteIdempotentRequest(userID uint, key string, statusCode int, contentType string, body []byte) error {
+	return database.GetDB().Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"content_type":  contentType,
+			"response_body": body,
+		}).Error
+}
+
+// ReleaseIdempotentRequest frees a claimed key without storing a response, so a request
+// that failed on the server's side can be retried with the same key
+func ReleaseIdempotentRequest(userID uint, key string) error {
+	return database.GetDB().Unscoped().
+		Where("user_id = ? AND key = ?", userID, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// DeleteExpiredIdempotencyKeys removes keys past their expiry
+func DeleteExpiredIdempotencyKeys() error {
+	return database.GetDB().Unscoped().
+		Where("expires_at < ?", time.Now().UTC()).
+		Delete(&models.IdempotencyKey{}).Error
+}
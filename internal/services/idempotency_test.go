@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestIdempotentReplayAndMismatch(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	const key, path = "trade-1", "/api/trades"
+	body := []byte(`{"ticker":"BP","side":"buy","quantity":1}`)
+	hash := HashRequest(http.MethodPost, path, body)
+
+	stored, err := BeginIdempotentRequest(user.ID, key, http.MethodPost, path, hash)
+	if err != nil || stored != nil {
+		t.Fatalf("first claim = %v, %v, want a new request", stored, err)
+	}
+
+	_, err = BeginIdempotentRequest(user.ID, key, http.MethodPost, path, hash)
+	if !errors.Is(err, ErrIdempotencyKeyInFlight) {
+		t.Fatalf("retry while in flight: err = %v, want %v", err, ErrIdempotencyKeyInFlight)
+	}
+
+	response := []byte(`{"id":1}`)
+	if err := CompleteIdempotentRequest(user.ID, key, http.StatusCreated, "application/json", response); err != nil {
+		t.Fatalf("CompleteIdempotentRequest: %v", err)
+	}
+
+	stored, err = BeginIdempotentRequest(user.ID, key, http.MethodPost, path, hash)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if stored == nil || stored.StatusCode != http.StatusCreated || string(stored.ResponseBody) != string(response) {
+		t.Fatalf("retry replayed %+v, want the stored %d %s", stored, http.StatusCreated, response)
+	}
+
+	conflicting := HashRequest(http.MethodPost, path, []byte(`{"ticker":"BP","side":"buy","quantity":2}`))
+	if _, err := BeginIdempotentRequest(user.ID, key, http.MethodPost, path, conflicting); !errors.Is(err, ErrIdempotencyKeyMismatch) {
+		t.Fatalf("different payload: err = %v, want %v", err, ErrIdempotencyKeyMismatch)
+	}
+
+	other := createTestUser(t, db)
+	if stored, err := BeginIdempotentRequest(other.ID, key, http.MethodPost, path, conflicting); err != nil || stored != nil {
+		t.Fatalf("another user's claim = %v, %v, want a new request", stored, err)
+	}
+}
+
+func TestReleasedIdempotencyKeyCanBeRetried(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	hash := HashRequest(http.MethodPost, "/api/trades", []byte(`{}`))
+	if _, err := BeginIdempotentRequest(user.ID, "k", http.MethodPost, "/api/trades", hash); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if err := ReleaseIdempotentRequest(user.ID, "k"); err != nil {
+		t.Fatalf("ReleaseIdempotentRequest: %v", err)
+	}
+	if stored, err := BeginIdempotentRequest(user.ID, "k", http.MethodPost, "/api/trades", hash); err != nil || stored != nil {
+		t.Fatalf("claim after release = %v, %v, want a new request", stored, err)
+	}
+}
@@ -0,0 +1,520 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/importer"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Import job statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+const (
+	// MaxImportSize is the largest file, in bytes, that can be imported
+	MaxImportSize = 10 << 20
+	// MaxImportErrors is how many skipped lines a job lists; ErrorCount still counts them all
+	MaxImportErrors = 1000
+	// importBatchSize is how many entries are inserted per statement
+	importBatchSize = 100
+)
+
+var nonTickerChars = regexp.MustCompile("[^A-Z0-9]+")
+
+// CreateImport reads a CSV export, or an export archive to restore, and records an import
+// job for it. A dry run is carried out straight away and saves nothing but the job; a real
+// import runs in the background and is followed through GetImport. The error is only for
+// files that can't be read at all.
+func CreateImport(userID uint, filename string, data []byte, opts importer.Options, dryRun bool) (*models.ImportJob, error) {
+	job := &models.ImportJob{
+		UserID:   userID,
+		Filename: filename,
+		DryRun:   dryRun,
+		Status:   ImportStatusPending,
+	}
+
+	var run func(job *models.ImportJob)
+	if IsArchive(data) {
+		a, err := readArchive(data)
+		if err != nil {
+			return nil, err
+		}
+		job.Format = ImportFormatArchive
+		job.TotalRows = a.recordCount()
+		run = func(job *models.ImportJob) { restoreArchive(job, a) }
+	} else {
+		parsed, err := importer.Parse(bytes.NewReader(data), opts)
+		if err != nil {
+			return nil, err
+		}
+		job.Format = parsed.Format
+		job.Units = opts.Units
+		if job.Units == "" {
+			job.Units = importer.UnitsMetric
+		}
+		job.TotalRows = parsed.TotalRows
+		run = func(job *models.ImportJob) { runImport(job, parsed) }
+	}
+
+	if err := database.GetDB().Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		run(job)
+		return job, nil
+	}
+
+	// The job is returned as it was created; the background import updates its own copy
+	created := *job
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Import job %d panicked: %v", job.ID, r)
+				finishImport(job, fmt.Errorf("import stopped unexpectedly"))
+			}
+		}()
+		run(job)
+	}()
+	return &created, nil
+}
+
+// GetImports returns the user's import jobs, most recent first
+func GetImports(userID uint, limit int) ([]models.ImportJob, error) {
+	var jobs []models.ImportJob
+	err := database.GetDB().
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// GetImport returns one of the user's import jobs
+func GetImport(userID uint, jobID uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := database.GetDB().Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// importExercise is an exercise named in an import and the entries planned for it
+type importExercise struct {
+	exercise models.Exercise
+	created  bool
+	entries  []models.WorkoutEntry
+}
+
+// importPlan is everything an import would save
+type importPlan struct {
+	exercises  []*importExercise
+	errors     []importer.RowError
+	duplicates int
+}
+
+// runImport plans the job's entries and, unless it is a dry run, saves them
+func runImport(job *models.ImportJob, parsed *importer.Result) {
+	now := time.Now()
+	job.Status = ImportStatusRunning
+	job.StartedAt = &now
+	database.GetDB().Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": now})
+
+	plan, err := planImport(job.UserID, parsed)
+	if err == nil && !job.DryRun {
+		err = applyImport(plan)
+	}
+
+	if plan != nil {
+		summarizeImport(job, plan)
+	}
+	finishImport(job, err)
+}
+
+// planImport maps every exercise name in the file to one of the user's exercises, or a new
+// one, and turns each exercise's sets in a workout into an entry. Lines that don't fit the
+// exercise's measurement type are reported, and entries the user already has are skipped.
+func planImport(userID uint, parsed *importer.Result) (*importPlan, error) {
+	db := database.GetDB()
+	plan := &importPlan{errors: parsed.Errors}
+
+	var existing []models.Exercise
+	if err := db.Where("user_id = ?", userID).Preload("CatalogExercise").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	var catalog []models.CatalogExercise
+	if err := db.Find(&catalog).Error; err != nil {
+		return nil, err
+	}
+
+	byName := map[string]models.Exercise{}
+	tickers := map[string]bool{}
+	for _, exercise := range existing {
+		tickers[exercise.Ticker] = true
+	}
+	for _, exercise := range existing {
+		if exercise.CatalogExercise != nil {
+			byName[importName(exercise.CatalogExercise.Name)] = exercise
+		}
+	}
+	for _, exercise := range existing {
+		byName[importName(exercise.Ticker)] = exercise
+	}
+	for _, exercise := range existing {
+		byName[importName(exercise.Name)] = exercise
+	}
+	catalogByName := map[string]models.CatalogExercise{}
+	for _, entry := range catalog {
+		catalogByName[importName(entry.Name)] = entry
+	}
+
+	// Group the rows into workouts of one exercise, in the order they appear
+	type group struct {
+		name string
+		rows []importer.Row
+	}
+	var groups []*group
+	groupIndex := map[string]*group{}
+	rowsByName := map[string][]importer.Row{}
+	var names []string
+	for _, row := range parsed.Rows {
+		name := importName(row.Exercise)
+		if _, ok := rowsByName[name]; !ok {
+			names = append(names, name)
+		}
+		rowsByName[name] = append(rowsByName[name], row)
+
+		key := row.Workout + "\x00" + name
+		g, ok := groupIndex[key]
+		if !ok {
+			g = &group{name: name}
+			groupIndex[key] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, row)
+	}
+
+	exercises := map[string]*importExercise{}
+	for _, name := range names {
+		rows := rowsByName[name]
+		if exercise, ok := byName[name]; ok {
+			exercises[name] = &importExercise{exercise: exercise}
+		} else {
+			exercises[name] = &importExercise{exercise: newImportExercise(userID, rows, catalogByName, tickers), created: true}
+		}
+		plan.exercises = append(plan.exercises, exercises[name])
+	}
+
+	seen, err := existingEntryKeys(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range groups {
+		target := exercises[g.name]
+		measurementType := MeasurementType(target.exercise)
+
+		var sets []models.WorkoutSet
+		for _, row := range g.rows {
+			set := models.WorkoutSet{
+				Type:     row.Type,
+				Weight:   row.Weight,
+				Reps:     row.Reps,
+				Duration: row.Duration,
+				Distance: row.Distance,
+				RPE:      row.RPE,
+				RIR:      row.RIR,
+			}
+			if err := ValidateSet(measurementType, &set); err != nil {
+				plan.errors = append(plan.errors, importer.RowError{Line: row.Line, Message: err.Error()})
+				continue
+			}
+			for i := 0; i < row.Sets; i++ {
+				sets = append(sets, set)
+			}
+		}
+		if len(sets) == 0 {
+			continue
+		}
+		if err := ValidateSets(measurementType, sets); err != nil {
+			plan.errors = append(plan.errors, importer.RowError{Line: g.rows[0].Line, Message: err.Error()})
+			continue
+		}
+
+		date := g.rows[0].Date
+		summary := SummarizeSets(measurementType, sets)
+		if !target.created && seen[entryKey(target.exercise.ID, date, summary)] {
+			plan.duplicates++
+			continue
+		}
+
+		score, err := ScoreEntry(target.exercise, sets, date, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		notes := ""
+		for _, row := range g.rows {
+			if row.Notes != "" {
+				notes = row.Notes
+				break
+			}
+		}
+
+		target.entries = append(target.entries, models.WorkoutEntry{
+			UserID:          userID,
+			ExerciseID:      target.exercise.ID,
+			Weight:          summary.Weight,
+			Reps:            summary.Reps,
+			Sets:            summary.Sets,
+			Duration:        summary.Duration,
+			Distance:        summary.Distance,
+			Notes:           notes,
+			Date:            date,
+			Score:           score.Value,
+			ScoringStrategy: score.Strategy,
+			ScoringVersion:  score.Version,
+			WorkoutSets:     sets,
+		})
+	}
+
+	return plan, nil
+}
+
+// applyImport saves a plan's new exercises and entries, then rebuilds the PR history of
+// every exercise that gained entries once rather than checking records row by row, and
+// reprices them
+func applyImport(plan *importPlan) error {
+	var touched []uint
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, target := range plan.exercises {
+			if target.created {
+				if len(target.entries) == 0 {
+					continue
+				}
+				if err := tx.Create(&target.exercise).Error; err != nil {
+					return err
+				}
+			}
+			if len(target.entries) == 0 {
+				continue
+			}
+
+			for i := range target.entries {
+				target.entries[i].ExerciseID = target.exercise.ID
+			}
+			if err := tx.CreateInBatches(&target.entries, importBatchSize).Error; err != nil {
+				return err
+			}
+			if err := rebuildPRHistory(tx, target.exercise.ID); err != nil {
+				return err
+			}
+			touched = append(touched, target.exercise.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, exerciseID := range touched {
+		if _, err := RepriceExercise(exerciseID, PriceReasonImported); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarizeImport copies a plan's counts, exercise mapping and errors onto its job
+func summarizeImport(job *models.ImportJob, plan *importPlan) {
+	job.EntryCount, job.SetCount, job.CreatedExercises = 0, 0, 0
+	job.Exercises = make([]models.ImportJobExercise, 0, len(plan.exercises))
+	for _, target := range plan.exercises {
+		if target.created && len(target.entries) == 0 {
+			continue
+		}
+		job.EntryCount += len(target.entries)
+		for _, entry := range target.entries {
+			job.SetCount += len(entry.WorkoutSets)
+		}
+		if target.created {
+			job.CreatedExercises++
+		}
+		job.Exercises = append(job.Exercises, models.ImportJobExercise{
+			Name:            target.exercise.Name,
+			ExerciseID:      target.exercise.ID,
+			Ticker:          target.exercise.Ticker,
+			MeasurementType: MeasurementType(target.exercise),
+			Created:         target.created,
+			EntryCount:      len(target.entries),
+		})
+	}
+	job.DuplicateCount = plan.duplicates
+
+	sort.SliceStable(plan.errors, func(i, j int) bool { return plan.errors[i].Line < plan.errors[j].Line })
+	job.ErrorCount = len(plan.errors)
+	job.Errors = make([]models.ImportJobError, 0, min(len(plan.errors), MaxImportErrors))
+	for _, rowErr := range plan.errors[:min(len(plan.errors), MaxImportErrors)] {
+		job.Errors = append(job.Errors, models.ImportJobError{Line: rowErr.Line, Message: rowErr.Message})
+	}
+}
+
+// finishImport marks a job completed, or failed with err
+func finishImport(job *models.ImportJob, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = ImportStatusCompleted
+	if err != nil {
+		job.Status = ImportStatusFailed
+		job.Failure = err.Error()
+	}
+	if err := database.GetDB().Save(job).Error; err != nil {
+		log.Printf("Failed to save import job %d: %v", job.ID, err)
+	}
+}
+
+// FailInterruptedImports marks the import jobs left pending or running by a server that
+// stopped mid-import as failed. Imports run in the server process, so at startup none can
+// still be in progress.
+func FailInterruptedImports() error {
+	now := time.Now()
+	result := database.GetDB().Model(&models.ImportJob{}).
+		Where("status IN ?", []string{ImportStatusPending, ImportStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      ImportStatusFailed,
+			"failure":     "import interrupted by a server restart",
+			"finished_at": now,
+		})
+	if result.RowsAffected > 0 {
+		log.Printf("Marked %d interrupted import jobs as failed", result.RowsAffected)
+	}
+	return result.Error
+}
+
+// newImportExercise builds the exercise an unknown name becomes, linked to the catalog
+// exercise of the same name if there is one. Otherwise its measurement type is inferred
+// from what the file recorded for it. tickers holds the tickers already taken.
+func newImportExercise(userID uint, rows []importer.Row, catalog map[string]models.CatalogExercise, tickers map[string]bool) models.Exercise {
+	exercise := models.Exercise{
+		UserID:     userID,
+		Name:       strings.TrimSpace(rows[0].Exercise),
+		StockPrice: InitialStockPrice,
+	}
+
+	if entry, ok := catalog[importName(exercise.Name)]; ok {
+		exercise.CatalogExerciseID = &entry.ID
+		exercise.Name = entry.Name
+		exercise.Description = entry.Description
+		exercise.Category = entry.Category
+		exercise.MeasurementType = entry.MeasurementType
+		if !tickers[entry.Ticker] {
+			exercise.Ticker = entry.Ticker
+		}
+	} else {
+		exercise.MeasurementType = inferMeasurementType(rows)
+		exercise.Category = rows[0].Category
+		if exercise.Category == "" {
+			exercise.Category = "Strength"
+			if exercise.MeasurementType == MeasurementTime || exercise.MeasurementType == MeasurementDistanceTime {
+				exercise.Category = "Cardio"
+			}
+		}
+	}
+
+	if exercise.Ticker == "" {
+		exercise.Ticker = generateTicker(exercise.Name, tickers)
+	}
+	tickers[exercise.Ticker] = true
+	return exercise
+}
+
+// inferMeasurementType guesses a measurement type from the first working set that recorded
+// anything
+func inferMeasurementType(rows []importer.Row) string {
+	for _, row := range rows {
+		if row.Type == importer.SetTypeWarmup {
+			continue
+		}
+		switch {
+		case row.Distance > 0:
+			return MeasurementDistanceTime
+		case row.Weight > 0 && row.Reps > 0:
+			return MeasurementWeightReps
+		case row.Reps > 0:
+			return MeasurementReps
+		case row.Duration > 0:
+			return MeasurementTime
+		}
+	}
+	return MeasurementWeightReps
+}
+
+// generateTicker makes a ticker from an exercise name: the initials of a name of several
+// words or the start of a single word, numbered when it is already taken
+func generateTicker(name string, tickers map[string]bool) string {
+	words := strings.Fields(nonTickerChars.ReplaceAllString(strings.ToUpper(name), " "))
+
+	base := ""
+	if len(words) > 1 {
+		for _, word := range words {
+			base += word[:1]
+		}
+	} else if len(words) == 1 {
+		base = words[0]
+	}
+	if len(base) > 6 {
+		base = base[:6]
+	}
+	if len(base) < 2 {
+		base = (base + "EX")[:2]
+	}
+
+	ticker := base
+	for n := 2; tickers[ticker]; n++ {
+		ticker = base + strconv.Itoa(n)
+	}
+	return ticker
+}
+
+// existingEntryKeys returns a key for every entry the user already has, so re-importing a
+// file doesn't log the same workouts twice
+func existingEntryKeys(db *gorm.DB, userID uint) (map[string]bool, error) {
+	var entries []models.WorkoutEntry
+	err := db.
+		Select("exercise_id", "date", "weight", "reps", "sets", "duration", "distance").
+		Where("user_id = ?", userID).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		keys[entryKey(entry.ExerciseID, entry.Date, EntryPerformance(entry))] = true
+	}
+	return keys, nil
+}
+
+func entryKey(exerciseID uint, date time.Time, p Performance) string {
+	return fmt.Sprintf("%d|%d|%g|%d|%d|%d|%g", exerciseID, date.Unix(), p.Weight, p.Reps, p.Sets, p.Duration, p.Distance)
+}
+
+// importName normalises an exercise name for matching
+func importName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
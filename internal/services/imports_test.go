@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+
+	"fitness-market/internal/importer"
+)
+
+func TestInferMeasurementType(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []importer.Row
+		want string
+	}{
+		{
+			name: "weight and reps",
+			rows: []importer.Row{{Type: importer.SetTypeWorking, Weight: 60, Reps: 5}},
+			want: MeasurementWeightReps,
+		},
+		{
+			name: "reps only",
+			rows: []importer.Row{{Type: importer.SetTypeWorking, Reps: 12}},
+			want: MeasurementReps,
+		},
+		{
+			name: "duration only",
+			rows: []importer.Row{{Type: importer.SetTypeWorking, Duration: 60}},
+			want: MeasurementTime,
+		},
+		{
+			name: "distance wins over duration",
+			rows: []importer.Row{{Type: importer.SetTypeWorking, Duration: 600, Distance: 2000}},
+			want: MeasurementDistanceTime,
+		},
+		{
+			name: "warmups are skipped",
+			rows: []importer.Row{
+				{Type: importer.SetTypeWarmup, Reps: 10},
+				{Type: importer.SetTypeWorking, Weight: 100, Reps: 5},
+			},
+			want: MeasurementWeightReps,
+		},
+		{
+			name: "empty rows are skipped",
+			rows: []importer.Row{
+				{Type: importer.SetTypeWorking},
+				{Type: importer.SetTypeWorking, Duration: 45},
+			},
+			want: MeasurementTime,
+		},
+		{
+			name: "nothing recorded",
+			rows: []importer.Row{{Type: importer.SetTypeWorking}},
+			want: MeasurementWeightReps,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferMeasurementType(tt.rows); got != tt.want {
+				t.Errorf("inferMeasurementType = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTicker(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{name: "Bench Press", want: "BP"},
+		{name: "Barbell Back Squat (High Bar)", want: "BBSHB"},
+		{name: "Squat", want: "SQUAT"},
+		{name: "Deadlifts", want: "DEADLI"},
+		{name: "X", want: "XE"},
+		{name: "!!", want: "EX"},
+		{name: "Bench Press", taken: []string{"BP"}, want: "BP2"},
+		{name: "Bench Press", taken: []string{"BP", "BP2"}, want: "BP3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tickers := map[string]bool{}
+			for _, ticker := range tt.taken {
+				tickers[ticker] = true
+			}
+			if got := generateTicker(tt.name, tickers); got != tt.want {
+				t.Errorf("generateTicker(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Bench Press", "bench press"},
+		{"  bench   PRESS ", "bench press"},
+		{"Bench\tPress", "bench press"},
+	}
+
+	for _, tt := range tests {
+		if got := importName(tt.name); got != tt.want {
+			t.Errorf("importName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
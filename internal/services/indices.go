@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// IndexBaseValue is the level every index starts at
+	IndexBaseValue = 1000.0
+	// OverallIndexSymbol is the index made up of all of a user's tickers
+	OverallIndexSymbol = "ALL"
+)
+
+var nonSymbolChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// MarketIndex is the current level of one of a user's indices
+type MarketIndex struct {
+	Symbol        string  `json:"symbol"`
+	Value         float64 `json:"value"`
+	Constituents  int     `json:"constituents"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// IndexSymbol converts an exercise category into its index symbol, e.g. "Strength" -> "STRENGTH"
+func IndexSymbol(category string) string {
+	symbol := nonSymbolChars.ReplaceAllString(strings.ToUpper(strings.TrimSpace(category)), "_")
+	return strings.Trim(symbol, "_")
+}
+
+// ComputeIndices calculates every index for the user at the given time.
+// Indices are equal-weighted: each constituent contributes its price relative to its
+// listing price, so adding a new ticker never makes an index jump.
+func ComputeIndices(userID uint, at time.Time) ([]MarketIndex, error) {
+	at = at.UTC()
+
+	var exercises []models.Exercise
+	err := database.GetDB().Unscoped().
+		Where("user_id = ? AND created_at <= ?", userID, at).
+		Where("deleted_at IS NULL OR deleted_at > ?", at).
+		Find(&exercises).Error
+	if err != nil {
+		return nil, err
+	}
+
+	relatives := map[string][]float64{}
+	for _, exercise := range exercises {
+		listing, err := GetListingPrice(exercise)
+		if err != nil {
+			return nil, err
+		}
+		if listing <= 0 {
+			continue
+		}
+		price, err := GetStockPriceAt(exercise, at)
+		if err != nil {
+			return nil, err
+		}
+		// Undo splits so they don't register as a crash in the index
+		factor, err := GetSplitFactor(exercise.ID, time.Time{}, at)
+		if err != nil {
+			return nil, err
+		}
+
+		relative := price * factor / listing
+		relatives[OverallIndexSymbol] = append(relatives[OverallIndexSymbol], relative)
+		if symbol := IndexSymbol(exercise.Category); symbol != "" && symbol != OverallIndexSymbol {
+			relatives[symbol] = append(relatives[symbol], relative)
+		}
+	}
+
+	indices := make([]MarketIndex, 0, len(relatives))
+	for symbol, values := range relatives {
+		indices = append(indices, MarketIndex{
+			Symbol:       symbol,
+			Value:        roundPrice(IndexBaseValue * average(values)),
+			Constituents: len(values),
+		})
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].Symbol < indices[j].Symbol
+	})
+
+	return indices, nil
+}
+
+// GetCurrentIndices returns the user's indices now, with the change since the previous day's close
+func GetCurrentIndices(userID uint) ([]MarketIndex, error) {
+	now := time.Now()
+	today := CalendarDate(now, GetUserLocation(userID))
+
+	indices, err := ComputeIndices(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range indices {
+		var previous models.IndexValue
+		err := database.GetDB().
+			Where("user_id = ? AND symbol = ? AND date < ?", userID, indices[i].Symbol, today).
+			Order("date DESC").
+			First(&previous).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		indices[i].Change = roundPrice(indices[i].Value - previous.Value)
+		indices[i].ChangePercent = percentChange(previous.Value, indices[i].Value)
+	}
+
+	return indices, nil
+}
+
+// GetIndexHistory returns the stored daily closes of one index within the range, oldest first
+func GetIndexHistory(userID uint, symbol string, rangeKey string) ([]models.IndexValue, error) {
+	today := CalendarDate(time.Now(), GetUserLocation(userID))
+	from, err := HistoryRangeStart(rangeKey, today)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []models.IndexValue
+	err = database.GetDB().
+		Where("user_id = ? AND symbol = ? AND date >= ?", userID, symbol, from).
+		Order("date ASC").
+		Find(&history).Error
+
+	return history, err
+}
+
+// RecordDueIndices stores the daily close of every index for each user-local day
+// that has ended since the last recorded close
+func RecordDueIndices() error {
+	db := database.GetDB()
+
+	var userIDs []uint
+	if err := db.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		if err := recordUserIndices(userID, now); err != nil {
+			log.Printf("Failed to record indices for user %d: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// recordUserIndices stores the user's index closes for the days since the last one stored
+func recordUserIndices(userID uint, now time.Time) error {
+	db := database.GetDB()
+	loc := GetUserLocation(userID)
+
+	var last *time.Time
+	var latest models.IndexValue
+	err := db.Where("user_id = ?", userID).Order("date DESC").First(&latest).Error
+	if err == nil {
+		last = &latest.Date
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	days, err := dueDays(userID, now, loc, last)
+	if err != nil || len(days) == 0 {
+		return err
+	}
+
+	// Days without a listed exercise have no indices to store, and would otherwise be
+	// recomputed on every run as no close is ever recorded for them
+	var listings []models.Exercise
+	err = db.Unscoped().Select("created_at", "deleted_at").Where("user_id = ?", userID).Find(&listings).Error
+	if err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		end := EndOfDay(day, loc)
+		if !anyListedAt(listings, end) {
+			continue
+		}
+		indices, err := ComputeIndices(userID, end)
+		if err != nil {
+			return err
+		}
+		for _, index := range indices {
+			var value models.IndexValue
+			err := db.Where(models.IndexValue{UserID: userID, Symbol: index.Symbol, Date: day}).
+				Assign(map[string]interface{}{
+					"value":        index.Value,
+					"constituents": index.Constituents,
+				}).
+				FirstOrCreate(&value).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// anyListedAt reports whether any of the exercises was listed at the given time, the
+// constituents ComputeIndices would consider
+func anyListedAt(exercises []models.Exercise, at time.Time) bool {
+	for _, exercise := range exercises {
+		if !exercise.CreatedAt.After(at) && (!exercise.DeletedAt.Valid || exercise.DeletedAt.Time.After(at)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Cash ledger entry types
+const (
+	LedgerTypeDeposit   = "deposit"
+	LedgerTypeDividend  = "dividend"
+	LedgerTypeTradeBuy  = "trade_buy"
+	LedgerTypeTradeSell = "trade_sell"
+)
+
+// GetCashBalance returns the user's current virtual cash balance
+func GetCashBalance(userID uint) (float64, error) {
+	return cashBalance(database.GetDB(), userID)
+}
+
+// GetCashLedger returns the user's most recent cash movements, newest first
+func GetCashLedger(userID uint, limit int) ([]models.CashLedgerEntry, error) {
+	var entries []models.CashLedgerEntry
+	err := database.GetDB().
+		Where("user_id = ?", userID).
+		Order("occurred_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error
+
+	return entries, err
+}
+
+// PostLedgerEntry appends a cash movement for the entry's user, filling in the running balance.
+// Pass a transaction so the balance read and the insert can't interleave with another posting.
+func PostLedgerEntry(tx *gorm.DB, entry *models.CashLedgerEntry) error {
+	balance, err := cashBalance(tx, entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	entry.Balance = roundPrice(balance + entry.Amount)
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = time.Now()
+	}
+
+	return tx.Create(entry).Error
+}
+
+func cashBalance(db *gorm.DB, userID uint) (float64, error) {
+	var balance float64
+	err := db.Model(&models.CashLedgerEntry{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+
+	return roundPrice(balance), err
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+)
+
+// Measurement types an exercise can declare
+const (
+	MeasurementWeightReps         = "weight_reps"
+	MeasurementReps               = "reps"
+	MeasurementTime               = "time"
+	MeasurementDistanceTime       = "distance_time"
+	MeasurementWeightedBodyweight = "weighted_bodyweight"
+	MeasurementAssisted           = "assisted"
+)
+
+// ErrInvalidMeasurementType is returned for a measurement type that isn't one of the above
+var ErrInvalidMeasurementType = errors.New("measurement_type must be one of weight_reps, reps, time, distance_time, weighted_bodyweight or assisted")
+
+// Performance is what a workout entry records. Weight is in kilograms and is the added
+// load on weighted_bodyweight exercises and the assistance on assisted ones; Duration is
+// in seconds and Distance in metres.
+type Performance struct {
+	Weight   float64 `json:"weight"`
+	Reps     int     `json:"reps"`
+	Sets     int     `json:"sets"`
+	Duration int     `json:"duration"`
+	Distance float64 `json:"distance"`
+}
+
+// EntryPerformance returns the performance recorded on an entry
+func EntryPerformance(entry models.WorkoutEntry) Performance {
+	return Performance{
+		Weight:   entry.Weight,
+		Reps:     entry.Reps,
+		Sets:     entry.Sets,
+		Duration: entry.Duration,
+		Distance: entry.Distance,
+	}
+}
+
+// ValidateMeasurementType checks a measurement type is known
+func ValidateMeasurementType(measurementType string) error {
+	switch measurementType {
+	case MeasurementWeightReps, MeasurementReps, MeasurementTime, MeasurementDistanceTime,
+		MeasurementWeightedBodyweight, MeasurementAssisted:
+		return nil
+	}
+	return ErrInvalidMeasurementType
+}
+
+// MeasurementType returns the measurement type of an exercise, treating exercises created
+// before measurement types existed as weight_reps
+func MeasurementType(exercise models.Exercise) string {
+	if exercise.MeasurementType == "" {
+		return MeasurementWeightReps
+	}
+	return exercise.MeasurementType
+}
+
+// NormalizePerformance fills in a single set for timed and distance exercises, where sets
+// are optional
+func NormalizePerformance(measurementType string, p Performance) Performance {
+	if (measurementType == MeasurementTime || measurementType == MeasurementDistanceTime) && p.Sets == 0 {
+		p.Sets = 1
+	}
+	return p
+}
+
+// ValidatePerformance checks that a performance has the fields its measurement type needs
+// and none that it doesn't
+func ValidatePerformance(measurementType string, p Performance) error {
+	if p.Weight < 0 || p.Reps < 0 || p.Sets < 0 || p.Duration < 0 || p.Distance < 0 {
+		return errors.New("weight, reps, sets, duration and distance cannot be negative")
+	}
+
+	var required, unused []string
+	switch measurementType {
+	case MeasurementWeightReps:
+		required, unused = []string{"weight", "reps", "sets"}, []string{"duration", "distance"}
+	case MeasurementReps:
+		required, unused = []string{"reps", "sets"}, []string{"weight", "duration", "distance"}
+	case MeasurementTime:
+		required, unused = []string{"duration", "sets"}, []string{"weight", "reps", "distance"}
+	case MeasurementDistanceTime:
+		required, unused = []string{"distance", "duration", "sets"}, []string{"weight", "reps"}
+	case MeasurementWeightedBodyweight, MeasurementAssisted:
+		required, unused = []string{"reps", "sets"}, []string{"duration", "distance"}
+	default:
+		return ErrInvalidMeasurementType
+	}
+
+	values := map[string]float64{
+		"weight":   p.Weight,
+		"reps":     float64(p.Reps),
+		"sets":     float64(p.Sets),
+		"duration": float64(p.Duration),
+		"distance": p.Distance,
+	}
+	for _, field := range required {
+		if values[field] <= 0 {
+			return fmt.Errorf("%s is required and must be greater than 0 for %s exercises", field, measurementType)
+		}
+	}
+	for _, field := range unused {
+		if values[field] != 0 {
+			return fmt.Errorf("%s is not used by %s exercises", field, measurementType)
+		}
+	}
+	return nil
+}
+
+// usesWeight reports whether a measurement type records a weight, the load lifted or the
+// load added to or taken off bodyweight
+func usesWeight(measurementType string) bool {
+	switch measurementType {
+	case MeasurementWeightReps, MeasurementWeightedBodyweight, MeasurementAssisted:
+		return true
+	default:
+		return false
+	}
+}
+
+// effectiveLoad is the weight actually moved: bodyweight plus the added load on weighted
+// bodyweight exercises, bodyweight less the assistance on assisted ones
+func effectiveLoad(measurementType string, weight float64, bodyweight float64) float64 {
+	switch measurementType {
+	case MeasurementWeightedBodyweight:
+		return bodyweight + weight
+	case MeasurementAssisted:
+		return math.Max(bodyweight-weight, 0)
+	default:
+		return weight
+	}
+}
+
+// measurementScorer returns the scorer for exercises that aren't scored by load, or nil
+// when the exercise's scoring strategy applies
+func measurementScorer(measurementType string) scoring.Scorer {
+	switch measurementType {
+	case MeasurementReps:
+		return scoring.TotalReps
+	case MeasurementTime:
+		return scoring.TotalDuration
+	case MeasurementDistanceTime:
+		return scoring.Speed
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+)
+
+// GetNotifications returns up to limit of the user's notifications, newest first
+func GetNotifications(userID uint, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := database.GetDB().Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&notifications).Error
+
+	return notifications, err
+}
+
+// CountUnreadNotifications returns how many of the user's notifications are unread
+func CountUnreadNotifications(userID uint) (int64, error) {
+	var count int64
+	err := database.GetDB().Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+
+	return count, err
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+func MarkNotificationRead(userID uint, notificationID uint) (*models.Notification, error) {
+	db := database.GetDB()
+
+	var notification models.Notification
+	if err := db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		return nil, err
+	}
+	if notification.ReadAt != nil {
+		return &notification, nil
+	}
+
+	now := time.Now()
+	if err := db.Model(&notification).UpdateColumn("read_at", now).Error; err != nil {
+		return nil, err
+	}
+	notification.ReadAt = &now
+
+	return &notification, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user's as read and returns how many changed
+func MarkAllNotificationsRead(userID uint) (int64, error) {
+	result := database.GetDB().Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		UpdateColumn("read_at", time.Now())
+
+	return result.RowsAffected, result.Error
+}
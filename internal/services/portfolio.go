@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidHistoryRange is returned for a history range other than 1m, 3m, 1y or all
+var ErrInvalidHistoryRange = errors.New("range must be one of 1m, 3m, 1y or all")
+
+// PortfolioHolding is a single ticker's position in the portfolio, marked to market
+type PortfolioHolding struct {
+	ExerciseID        uint    `json:"exercise_id"`
+	Ticker            string  `json:"ticker"`
+	Name              string  `json:"name"`
+	Category          string  `json:"category"`
+	Shares            float64 `json:"shares"`
+	AverageCost       float64 `json:"average_cost"`
+	Price             float64 `json:"price"`
+	Value             float64 `json:"value"`
+	UnrealizedGain    float64 `json:"unrealized_gain"`
+	DayChange         float64 `json:"day_change"`
+	DayChangePercent  float64 `json:"day_change_percent"`
+	WeekChange        float64 `json:"week_change"`
+	WeekChangePercent float64 `json:"week_change_percent"`
+}
+
+// CategoryAllocation is the share of portfolio value held in one exercise category
+type CategoryAllocation struct {
+	Category string  `json:"category"`
+	Value    float64 `json:"value"`
+	Percent  float64 `json:"percent"`
+}
+
+// PortfolioOverview is the current state of a user's portfolio
+type PortfolioOverview struct {
+	TotalValue        float64              `json:"total_value"`
+	DayChange         float64              `json:"day_change"`
+	DayChangePercent  float64              `json:"day_change_percent"`
+	WeekChange        float64              `json:"week_change"`
+	WeekChangePercent float64              `json:"week_change_percent"`
+	CashBalance       float64              `json:"cash_balance"`
+	HoldingsValue     float64              `json:"holdings_value"`
+	DividendIncome    float64              `json:"dividend_income"`
+	Holdings          []PortfolioHolding   `json:"holdings"`
+	Allocation        []CategoryAllocation `json:"allocation"`
+	AsOf              time.Time            `json:"as_of"`
+}
+
+// PortfolioHistoryPoint is one snapshot with its change versus the previous snapshot
+type PortfolioHistoryPoint struct {
+	Date          time.Time `json:"date"`
+	TotalValue    float64   `json:"total_value"`
+	WorkoutCount  int       `json:"workout_count"`
+	ActiveStreaks int       `json:"active_streaks"`
+	Change        float64   `json:"change"`
+	ChangePercent float64   `json:"change_percent"`
+}
+
+// GetPortfolioOverview values the user's cash plus every share they hold at current prices,
+// and compares the total against the previous day's and week-ago snapshots
+func GetPortfolioOverview(userID uint) (*PortfolioOverview, error) {
+	now := time.Now()
+	loc := GetUserLocation(userID)
+	today := CalendarDate(now, loc)
+	dayStart := EndOfDay(today.AddDate(0, 0, -1), loc)
+	weekStart := EndOfDay(today.AddDate(0, 0, -8), loc)
+
+	if err := EnsureStartingCash(userID); err != nil {
+		return nil, err
+	}
+	cash, err := GetCashBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings, err := GetHoldings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &PortfolioOverview{
+		CashBalance: cash,
+		Holdings:    []PortfolioHolding{},
+		Allocation:  []CategoryAllocation{},
+		AsOf:        now,
+	}
+	categoryValues := map[string]float64{}
+
+	for _, holding := range holdings {
+		exercise := holding.Exercise
+		dayPrice, err := GetAdjustedStockPriceAt(exercise, dayStart)
+		if err != nil {
+			return nil, err
+		}
+		weekPrice, err := GetAdjustedStockPriceAt(exercise, weekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		value := roundPrice(holding.Shares * exercise.StockPrice)
+		position := PortfolioHolding{
+			ExerciseID:        exercise.ID,
+			Ticker:            exercise.Ticker,
+			Name:              exercise.Name,
+			Category:          exercise.Category,
+			Shares:            holding.Shares,
+			AverageCost:       roundPrice(holding.AverageCost),
+			Price:             exercise.StockPrice,
+			Value:             value,
+			UnrealizedGain:    roundPrice(value - holding.Shares*holding.AverageCost),
+			DayChange:         roundPrice(holding.Shares * (exercise.StockPrice - dayPrice)),
+			DayChangePercent:  percentChange(dayPrice, exercise.StockPrice),
+			WeekChange:        roundPrice(holding.Shares * (exercise.StockPrice - weekPrice)),
+			WeekChangePercent: percentChange(weekPrice, exercise.StockPrice),
+		}
+
+		overview.Holdings = append(overview.Holdings, position)
+		overview.HoldingsValue += value
+		categoryValues[exercise.Category] += value
+	}
+	overview.HoldingsValue = roundPrice(overview.HoldingsValue)
+	overview.TotalValue = roundPrice(overview.CashBalance + overview.HoldingsValue)
+
+	for category, value := range categoryValues {
+		overview.Allocation = append(overview.Allocation, CategoryAllocation{
+			Category: category,
+			Value:    roundPrice(value),
+			Percent:  percentOf(value, overview.HoldingsValue),
+		})
+	}
+	sort.Slice(overview.Allocation, func(i, j int) bool {
+		return overview.Allocation[i].Value > overview.Allocation[j].Value
+	})
+
+	previous, err := latestSnapshotBefore(userID, today)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		overview.DayChange = roundPrice(overview.TotalValue - previous.TotalValue)
+		overview.DayChangePercent = percentChange(previous.TotalValue, overview.TotalValue)
+	}
+
+	weekAgo, err := latestSnapshotBefore(userID, today.AddDate(0, 0, -6))
+	if err != nil {
+		return nil, err
+	}
+	if weekAgo != nil {
+		overview.WeekChange = roundPrice(overview.TotalValue - weekAgo.TotalValue)
+		overview.WeekChangePercent = percentChange(weekAgo.TotalValue, overview.TotalValue)
+	}
+
+	if overview.DividendIncome, err = GetDividendIncome(userID); err != nil {
+		return nil, err
+	}
+
+	return overview, nil
+}
+
+// GetPortfolioHistory returns the user's snapshots within the range, oldest first
+func GetPortfolioHistory(userID uint, rangeKey string) ([]PortfolioHistoryPoint, error) {
+	today := CalendarDate(time.Now(), GetUserLocation(userID))
+	from, err := HistoryRangeStart(rangeKey, today)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []models.PortfolioSnapshot
+	err = database.GetDB().
+		Where("user_id = ? AND date >= ?", userID, from).
+		Order("date ASC").
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// The first point's change is measured against the snapshot just before the range
+	previous, err := latestSnapshotBefore(userID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]PortfolioHistoryPoint, 0, len(snapshots))
+	for i := range snapshots {
+		snapshot := snapshots[i]
+		point := PortfolioHistoryPoint{
+			Date:          snapshot.Date,
+			TotalValue:    snapshot.TotalValue,
+			WorkoutCount:  snapshot.WorkoutCount,
+			ActiveStreaks: snapshot.ActiveStreaks,
+		}
+		if previous != nil {
+			point.Change = roundPrice(snapshot.TotalValue - previous.TotalValue)
+			point.ChangePercent = percentChange(previous.TotalValue, snapshot.TotalValue)
+		}
+		points = append(points, point)
+		previous = &snapshot
+	}
+
+	return points, nil
+}
+
+// HistoryRangeStart returns the first calendar date covered by a 1m, 3m, 1y or all range.
+// The all range starts at the zero time.
+func HistoryRangeStart(rangeKey string, today time.Time) (time.Time, error) {
+	switch rangeKey {
+	case "1m":
+		return today.AddDate(0, -1, 0), nil
+	case "3m":
+		return today.AddDate(0, -3, 0), nil
+	case "1y":
+		return today.AddDate(-1, 0, 0), nil
+	case "all":
+		return time.Time{}, nil
+	default:
+		return time.Time{}, ErrInvalidHistoryRange
+	}
+}
+
+// latestSnapshotBefore returns the user's most recent snapshot dated before the given date, or nil
+func latestSnapshotBefore(userID uint, date time.Time) (*models.PortfolioSnapshot, error) {
+	var snapshot models.PortfolioSnapshot
+	err := database.GetDB().
+		Where("user_id = ? AND date < ?", userID, date).
+		Order("date DESC").
+		First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// percentChange returns the percentage move from previous to current, rounded to two places
+func percentChange(previous float64, current float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return math.Round((current-previous)/previous*10000) / 100
+}
+
+func percentOf(part float64, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(part/total*10000) / 100
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StreakWindowDays is how recently an exercise must be trained to count as an active streak
+const StreakWindowDays = 7
+
+// GenerateDueSnapshots writes portfolio snapshots for every user, covering each
+// user-local day that has ended since their last snapshot
+func GenerateDueSnapshots() error {
+	db := database.GetDB()
+
+	var userIDs []uint
+	if err := db.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		if _, err := GenerateUserSnapshots(userID, now); err != nil {
+			log.Printf("Failed to generate portfolio snapshots for user %d: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// GenerateUserSnapshots writes a snapshot for each of the user's completed local days
+// that doesn't have one yet, backfilling gaps left by downtime. It returns the number
+// of snapshots written.
+func GenerateUserSnapshots(userID uint, now time.Time) (int, error) {
+	loc := GetUserLocation(userID)
+
+	days, err := dueSnapshotDays(userID, now, loc)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, day := range days {
+		if _, err := SaveSnapshot(userID, day, loc); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(days), nil
+}
+
+// SaveSnapshot computes the snapshot for a calendar date and stores it.
+// Saving the same (user, date) twice updates the existing row instead of duplicating it.
+func SaveSnapshot(userID uint, date time.Time, loc *time.Location) (*models.PortfolioSnapshot, error) {
+	computed, err := ComputeSnapshot(userID, date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot models.PortfolioSnapshot
+	err = database.GetDB().
+		Where(models.PortfolioSnapshot{UserID: userID, Date: date}).
+		Assign(map[string]interface{}{
+			"total_value":    computed.TotalValue,
+			"workout_count":  computed.WorkoutCount,
+			"active_streaks": computed.ActiveStreaks,
+		}).
+		FirstOrCreate(&snapshot).Error
+
+	return &snapshot, err
+}
+
+// ComputeSnapshot calculates the user's portfolio as it stood at the end of a calendar date
+func ComputeSnapshot(userID uint, date time.Time, loc *time.Location) (*models.PortfolioSnapshot, error) {
+	db := database.GetDB()
+	dayEnd := EndOfDay(date, loc)
+
+	// Users who never opened their portfolio haven't been given their starting cash yet
+	if err := EnsureStartingCash(userID); err != nil {
+		return nil, err
+	}
+
+	// Value cash plus the shares held at the end of the day, at that day's prices
+	totalValue, err := GetCashBalanceAt(userID, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := GetSharesAt(userID, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	for exerciseID, count := range shares {
+		if count < shareEpsilon {
+			continue
+		}
+		var exercise models.Exercise
+		if err := db.Unscoped().First(&exercise, exerciseID).Error; err != nil {
+			return nil, err
+		}
+		price, err := GetStockPriceAt(exercise, dayEnd)
+		if err != nil {
+			return nil, err
+		}
+		totalValue += count * price
+	}
+
+	workoutCount, err := CountWorkouts(userID, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var activeStreaks int64
+	err = db.Model(&models.WorkoutEntry{}).
+		Where("user_id = ? AND date >= ? AND date < ?", userID, dayEnd.AddDate(0, 0, -StreakWindowDays), dayEnd).
+		Distinct("exercise_id").
+		Count(&activeStreaks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PortfolioSnapshot{
+		UserID:        userID,
+		Date:          date,
+		TotalValue:    roundPrice(totalValue),
+		WorkoutCount:  int(workoutCount),
+		ActiveStreaks: int(activeStreaks),
+	}, nil
+}
+
+// dueSnapshotDays lists the completed calendar dates after the user's latest snapshot
+func dueSnapshotDays(userID uint, now time.Time, loc *time.Location) ([]time.Time, error) {
+	var latest models.PortfolioSnapshot
+	err := database.GetDB().Where("user_id = ?", userID).Order("date DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dueDays(userID, now, loc, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dueDays(userID, now, loc, &latest.Date)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+func TestSnapshotsHoldStartingCashFromSignup(t *testing.T) {
+	db := setupTestDB(t)
+	user := models.User{Email: "cash@example.com", Password: "x", CreatedAt: time.Now().AddDate(0, 0, -3)}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// The user never opened their portfolio, so nothing has posted their deposit yet
+	written, err := GenerateUserSnapshots(user.ID, time.Now())
+	if err != nil {
+		t.Fatalf("GenerateUserSnapshots: %v", err)
+	}
+	if written == 0 {
+		t.Fatal("no snapshots written")
+	}
+
+	var snapshots []models.PortfolioSnapshot
+	if err := db.Where("user_id = ?", user.ID).Order("date ASC").Find(&snapshots).Error; err != nil {
+		t.Fatalf("load snapshots: %v", err)
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.TotalValue != StartingCashBalance {
+			t.Errorf("snapshot of %s = %.2f, want %.2f", snapshot.Date.Format("2006-01-02"), snapshot.TotalValue, StartingCashBalance)
+		}
+	}
+
+	var deposit models.CashLedgerEntry
+	if err := db.Where("user_id = ? AND type = ?", user.ID, LedgerTypeDeposit).First(&deposit).Error; err != nil {
+		t.Fatalf("load deposit: %v", err)
+	}
+	if !deposit.OccurredAt.Equal(user.CreatedAt) {
+		t.Errorf("deposit occurred at %s, want the signup time %s", deposit.OccurredAt, user.CreatedAt)
+	}
+}
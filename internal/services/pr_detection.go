@@ -1,107 +1,416 @@
 package services
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"fitness-market/internal/database"
 	"fitness-market/internal/models"
-	"time"
+	"fitness-market/internal/scoring"
+
+	"gorm.io/gorm"
 )
 
-// PRDetectionResult contains the result of PR detection
-type PRDetectionResult struct {
-	IsPR            bool    `json:"is_pr"`
-	Score           float64 `json:"score"`
+// PR record types
+const (
+	PRTypeScore  = "score"
+	PRTypeWeight = "weight"
+	PRTypeE1RM   = "e1rm"
+	PRTypeRepMax = "rep_max"
+	PRTypeVolume = "volume"
+
+	PRTypeMaxReps  = "max_reps"
+	PRTypeDuration = "duration"
+	PRTypeDistance = "distance"
+)
+
+// MaxRepMaxReps is the highest rep count tracked in the rep-max table
+const MaxRepMaxReps = 12
+
+// prTypeOrder is the order records are reported in
+var prTypeOrder = map[string]int{
+	PRTypeScore:  0,
+	PRTypeWeight: 1,
+	PRTypeE1RM:   2,
+	PRTypeRepMax: 3,
+	PRTypeVolume: 4,
+
+	PRTypeMaxReps:  5,
+	PRTypeDuration: 6,
+	PRTypeDistance: 7,
+}
+
+// PRRecord is a single personal record set by an entry. First marks the first value ever
+// recorded in its slot, which is a baseline for later entries rather than a PR.
+type PRRecord struct {
+	Type            string  `json:"type"`
+	Reps            int     `json:"reps,omitempty"`
+	Value           float64 `json:"value"`
 	PreviousBest    float64 `json:"previous_best,omitempty"`
 	Improvement     float64 `json:"improvement,omitempty"`
-	CelebrationText string  `json:"celebration_text,omitempty"`
+	CelebrationText string  `json:"celebration_text"`
+	First           bool    `json:"-"`
 }
 
-// CalculateScore calculates a performance score based on weight, reps, and sets
-// Using a standard formula: Score = Weight * Reps * Sets
-func CalculateScore(weight float64, reps int, sets int) float64 {
-	return weight * float64(reps) * float64(sets)
+// prKey identifies one record slot: a type, plus the rep count for rep_max records
+type prKey struct {
+	Type string
+	Reps int
 }
 
-// DetectPR compares a new entry against historical data to detect personal records
-func DetectPR(userID uint, exerciseID uint, weight float64, reps int, sets int) (*PRDetectionResult, error) {
-	db := database.GetDB()
-	newScore := CalculateScore(weight, reps, sets)
+// entryRecordValues returns what an entry measures for each record type its exercise's
+// measurement type can set, evaluated over each of its working sets. Types an entry has no
+// value for, such as weight on unloaded sets of a weighted bodyweight exercise, are left out.
+func entryRecordValues(entry models.WorkoutEntry, measurementType string) map[prKey]float64 {
+	values := map[prKey]float64{}
+	if entry.Score > 0 {
+		values[prKey{Type: PRTypeScore}] = entry.Score
+	}
 
-	result := &PRDetectionResult{
-		IsPR:  false,
-		Score: newScore,
+	// keepBest records a value for a slot if it beats the entry's other sets
+	keepBest := func(key prKey, value float64) {
+		if value > values[key] {
+			values[key] = value
+		}
 	}
 
-	// Find the current best PR for this exercise
-	var currentBestPR models.PRHistory
-	err := db.Where("user_id = ? AND exercise_id = ?", userID, exerciseID).
-		Order("score DESC").
-		First(&currentBestPR).Error
+	for _, set := range WorkingSets(EntrySets(entry)) {
+		switch measurementType {
+		case MeasurementWeightReps:
+			if set.Weight > 0 && set.Reps > 0 {
+				keepBest(prKey{Type: PRTypeWeight}, set.Weight)
+				keepBest(prKey{Type: PRTypeE1RM}, roundPrice(scoring.EpleyOneRepMax(set.Weight, set.Reps)))
+				if set.Reps <= MaxRepMaxReps {
+					keepBest(prKey{Type: PRTypeRepMax, Reps: set.Reps}, set.Weight)
+				}
+				values[prKey{Type: PRTypeVolume}] += set.Weight * float64(set.Reps)
+			}
+		case MeasurementWeightedBodyweight:
+			// Weight records track the added load
+			if set.Weight > 0 && set.Reps > 0 {
+				keepBest(prKey{Type: PRTypeWeight}, set.Weight)
+				if set.Reps <= MaxRepMaxReps {
+					keepBest(prKey{Type: PRTypeRepMax, Reps: set.Reps}, set.Weight)
+				}
+			}
+			if set.Reps > 0 {
+				keepBest(prKey{Type: PRTypeMaxReps}, float64(set.Reps))
+			}
+		case MeasurementReps, MeasurementAssisted:
+			if set.Reps > 0 {
+				keepBest(prKey{Type: PRTypeMaxReps}, float64(set.Reps))
+			}
+		case MeasurementTime:
+			if set.Duration > 0 {
+				keepBest(prKey{Type: PRTypeDuration}, float64(set.Duration))
+			}
+		case MeasurementDistanceTime:
+			if set.Distance > 0 {
+				keepBest(prKey{Type: PRTypeDistance}, set.Distance)
+			}
+			if set.Duration > 0 {
+				keepBest(prKey{Type: PRTypeDuration}, float64(set.Duration))
+			}
+		}
+	}
+	return values
+}
 
-	if err != nil {
-		// No previous PR exists, this is the first entry - it's a PR!
-		result.IsPR = true
-		result.CelebrationText = "🎉 First record set! Keep going!"
-		return result, nil
+// compareRecords returns the records an entry's values set against the current bests,
+// including first values in slots without a best yet, and updates bests with them
+func compareRecords(values map[prKey]float64, bests map[prKey]float64) []PRRecord {
+	records := []PRRecord{}
+	for key, value := range values {
+		best, exists := bests[key]
+		if exists && value <= best {
+			continue
+		}
+
+		record := PRRecord{Type: key.Type, Reps: key.Reps, Value: value, First: !exists}
+		if exists {
+			record.PreviousBest = best
+			record.Improvement = value - best
+			record.CelebrationText = celebrationText(record)
+		}
+		records = append(records, record)
+		bests[key] = value
 	}
 
-	// Compare against previous best
-	result.PreviousBest = currentBestPR.Score
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Type != records[j].Type {
+			return prTypeOrder[records[i].Type] < prTypeOrder[records[j].Type]
+		}
+		return records[i].Reps < records[j].Reps
+	})
+	return records
+}
 
-	if newScore > currentBestPR.Score {
-		result.IsPR = true
-		result.Improvement = newScore - currentBestPR.Score
-		result.CelebrationText = "🏆 New Personal Record! You beat your previous best!"
+// BrokenRecords returns the records that beat a previous best. First values are saved to
+// the PR history as baselines but are not PRs, so an exercise's first entry, or the first
+// set at a new rep count, is recorded silently.
+func BrokenRecords(records []PRRecord) []PRRecord {
+	broken := []PRRecord{}
+	for _, record := range records {
+		if !record.First {
+			broken = append(broken, record)
+		}
 	}
+	return broken
+}
 
-	return result, nil
+func celebrationText(record PRRecord) string {
+	switch record.Type {
+	case PRTypeScore:
+		return "🏆 New Personal Record! You beat your previous best!"
+	case PRTypeWeight:
+		return fmt.Sprintf("🏋️ Heaviest weight yet: %g, up %g!", record.Value, record.Improvement)
+	case PRTypeE1RM:
+		return fmt.Sprintf("💪 New estimated 1RM: %g!", record.Value)
+	case PRTypeRepMax:
+		return fmt.Sprintf("🔥 New %dRM: %g!", record.Reps, record.Value)
+	case PRTypeMaxReps:
+		return fmt.Sprintf("🔁 Most reps in a set: %g, up %g!", record.Value, record.Improvement)
+	case PRTypeDuration:
+		return fmt.Sprintf("⏱️ Longest yet: %gs, up %gs!", record.Value, record.Improvement)
+	case PRTypeDistance:
+		return fmt.Sprintf("📏 Farthest yet: %gm, up %gm!", record.Value, record.Improvement)
+	default:
+		return fmt.Sprintf("📈 Most volume in a session: %g!", record.Value)
+	}
 }
 
-// RecordPR saves a new PR to the PR history
-func RecordPR(userID uint, exerciseID uint, workoutEntryID uint, weight float64, reps int, sets int, achievedAt time.Time) error {
-	db := database.GetDB()
+// DetectPRs compares a scored entry's working sets against the user's PR history for the
+// exercise and returns every record they set, first values included; see BrokenRecords. Which records apply depends on the
+// measurement type: score always, then heaviest weight, estimated 1RM, rep maxes and volume
+// for weight x reps, most reps for bodyweight exercises, and longest duration and distance
+// for timed ones.
+func DetectPRs(entry models.WorkoutEntry, measurementType string) ([]PRRecord, error) {
+	bests, err := currentBests(database.GetDB(), entry.UserID, entry.ExerciseID)
+	if err != nil {
+		return nil, err
+	}
+	return compareRecords(entryRecordValues(entry, measurementType), bests), nil
+}
 
-	prHistory := models.PRHistory{
-		UserID:         userID,
-		ExerciseID:     exerciseID,
-		WorkoutEntryID: workoutEntryID,
-		Score:          CalculateScore(weight, reps, sets),
-		Weight:         weight,
-		Reps:           reps,
-		Sets:           sets,
-		AchievedAt:     achievedAt,
+// RecordPRs saves the records an entry broke to the PR history
+func RecordPRs(entry models.WorkoutEntry, records []PRRecord) error {
+	return recordPRs(database.GetDB(), entry, records)
+}
+
+func recordPRs(db *gorm.DB, entry models.WorkoutEntry, records []PRRecord) error {
+	for _, record := range records {
+		// Rep max records describe the set that set them rather than the entry's top set
+		weight, reps := entry.Weight, entry.Reps
+		if record.Type == PRTypeRepMax {
+			weight, reps = record.Value, record.Reps
+		}
+		prHistory := models.PRHistory{
+			UserID:         entry.UserID,
+			ExerciseID:     entry.ExerciseID,
+			WorkoutEntryID: entry.ID,
+			Type:           record.Type,
+			Value:          record.Value,
+			Score:          entry.Score,
+			Weight:         weight,
+			Reps:           reps,
+			Sets:           entry.Sets,
+			Duration:       entry.Duration,
+			Distance:       entry.Distance,
+			AchievedAt:     entry.Date,
+		}
+		if err := db.Create(&prHistory).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentBests returns the best value recorded in each record slot for an exercise
+func currentBests(db *gorm.DB, userID uint, exerciseID uint) (map[prKey]float64, error) {
+	var rows []struct {
+		Type  string
+		Reps  int
+		Value float64
+	}
+	err := db.Model(&models.PRHistory{}).
+		Select("type, reps, MAX(value) AS value").
+		Where("user_id = ? AND exercise_id = ?", userID, exerciseID).
+		Group("type, reps").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
 	}
 
-	return db.Create(&prHistory).Error
+	bests := map[prKey]float64{}
+	for _, row := range rows {
+		key := prKey{Type: row.Type}
+		if row.Type == PRTypeRepMax {
+			key.Reps = row.Reps
+		}
+		if best, exists := bests[key]; !exists || row.Value > best {
+			bests[key] = row.Value
+		}
+	}
+	return bests, nil
 }
 
-// GetPRHistory returns the PR history for a specific exercise
-func GetPRHistory(userID uint, exerciseID uint) ([]models.PRHistory, error) {
+// GetPRHistory returns the PR history for a specific exercise, optionally of one type
+func GetPRHistory(userID uint, exerciseID uint, prType string) ([]models.PRHistory, error) {
 	db := database.GetDB()
 	var history []models.PRHistory
 
-	err := db.Where("user_id = ? AND exercise_id = ?", userID, exerciseID).
-		Order("achieved_at DESC").
-		Find(&history).Error
+	query := db.Where("user_id = ? AND exercise_id = ?", userID, exerciseID)
+	if prType != "" {
+		query = query.Where("type = ?", prType)
+	}
+	err := query.Order("achieved_at DESC, id DESC").Find(&history).Error
 
 	return history, err
 }
 
-// GetAllPRs returns the current best PR for each exercise for a user
+// GetAllPRs returns the current best record of each type for each of the user's exercises,
+// with one rep_max record per rep count
 func GetAllPRs(userID uint) ([]models.PRHistory, error) {
 	db := database.GetDB()
-	var prs []models.PRHistory
-
-	// Get the best PR for each exercise using a subquery
-	subquery := db.Model(&models.PRHistory{}).
-		Select("exercise_id, MAX(score) as max_score").
-		Where("user_id = ?", userID).
-		Group("exercise_id")
+	var history []models.PRHistory
 
-	err := db.Model(&models.PRHistory{}).
-		Joins("JOIN (?) as best ON pr_history.exercise_id = best.exercise_id AND pr_history.score = best.max_score", subquery).
-		Where("pr_history.user_id = ?", userID).
+	err := db.Where("user_id = ?", userID).
 		Preload("Exercise").
-		Find(&prs).Error
+		Order("exercise_id ASC, achieved_at ASC, id ASC").
+		Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the first record to reach each slot's best value
+	type slot struct {
+		ExerciseID uint
+		Key        prKey
+	}
+	bestIndex := map[slot]int{}
+	var order []slot
+	for i, pr := range history {
+		s := slot{ExerciseID: pr.ExerciseID, Key: historyKey(pr)}
+		current, exists := bestIndex[s]
+		if !exists {
+			order = append(order, s)
+		}
+		if !exists || pr.Value > history[current].Value {
+			bestIndex[s] = i
+		}
+	}
+
+	prs := make([]models.PRHistory, 0, len(order))
+	for _, s := range order {
+		prs = append(prs, history[bestIndex[s]])
+	}
+	sort.SliceStable(prs, func(i, j int) bool {
+		if prs[i].ExerciseID != prs[j].ExerciseID {
+			return prs[i].ExerciseID < prs[j].ExerciseID
+		}
+		if prs[i].Type != prs[j].Type {
+			return prTypeOrder[prs[i].Type] < prTypeOrder[prs[j].Type]
+		}
+		return prs[i].Reps < prs[j].Reps
+	})
+
+	return prs, nil
+}
 
-	return prs, err
+// DisplacedRecord is a record a later entry held until a backdated entry beat it first
+type DisplacedRecord struct {
+	WorkoutEntryID uint      `json:"workout_entry_id"`
+	Type           string    `json:"type"`
+	Reps           int       `json:"reps,omitempty"`
+	Value          float64   `json:"value"`
+	AchievedAt     time.Time `json:"achieved_at"`
+}
+
+// IsBackdated reports whether an entry is dated before another entry of its exercise, so
+// that records later entries set may not have been records at the time after all
+func IsBackdated(entry models.WorkoutEntry) (bool, error) {
+	var count int64
+	err := database.GetDB().Model(&models.WorkoutEntry{}).
+		Where("exercise_id = ? AND date > ? AND id != ?", entry.ExerciseID, entry.Date.UTC(), entry.ID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ReplayPRHistory rebuilds the PR history of a saved entry's exercise in date order, so a
+// backdated entry is compared against the records as they stood on its date. It returns the
+// records the entry broke at the time and the records of later entries it displaced.
+func ReplayPRHistory(entry models.WorkoutEntry) ([]PRRecord, []DisplacedRecord, error) {
+	var records []PRRecord
+	displaced := []DisplacedRecord{}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var before []models.PRHistory
+		if err := tx.Where("exercise_id = ?", entry.ExerciseID).Find(&before).Error; err != nil {
+			return err
+		}
+
+		broken, err := replayPRHistory(tx, entry.ExerciseID)
+		if err != nil {
+			return err
+		}
+		records = broken[entry.ID]
+
+		var after []models.PRHistory
+		if err := tx.Where("exercise_id = ?", entry.ExerciseID).Find(&after).Error; err != nil {
+			return err
+		}
+
+		type slot struct {
+			EntryID uint
+			Key     prKey
+		}
+		kept := map[slot]bool{}
+		for _, pr := range after {
+			kept[slot{EntryID: pr.WorkoutEntryID, Key: historyKey(pr)}] = true
+		}
+		// The earliest row of each slot was a baseline rather than a PR, so losing it
+		// displaces nothing
+		baselines := map[prKey]models.PRHistory{}
+		for _, pr := range before {
+			first, exists := baselines[historyKey(pr)]
+			if !exists || pr.AchievedAt.Before(first.AchievedAt) || (pr.AchievedAt.Equal(first.AchievedAt) && pr.ID < first.ID) {
+				baselines[historyKey(pr)] = pr
+			}
+		}
+		for _, pr := range before {
+			if pr.WorkoutEntryID == entry.ID || kept[slot{EntryID: pr.WorkoutEntryID, Key: historyKey(pr)}] ||
+				baselines[historyKey(pr)].ID == pr.ID {
+				continue
+			}
+			record := DisplacedRecord{
+				WorkoutEntryID: pr.WorkoutEntryID,
+				Type:           pr.Type,
+				Value:          pr.Value,
+				AchievedAt:     pr.AchievedAt,
+			}
+			if pr.Type == PRTypeRepMax {
+				record.Reps = pr.Reps
+			}
+			displaced = append(displaced, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if records == nil {
+		records = []PRRecord{}
+	}
+	return records, displaced, nil
+}
+
+// historyKey returns the record slot a PR history row belongs to
+func historyKey(pr models.PRHistory) prKey {
+	key := prKey{Type: pr.Type}
+	if pr.Type == PRTypeRepMax {
+		key.Reps = pr.Reps
+	}
+	return key
 }
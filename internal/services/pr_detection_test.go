@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// logTestEntry saves an entry and records its PRs the way a newly logged, in-order entry is
+func logTestEntry(t *testing.T, db *gorm.DB, exercise models.Exercise, date time.Time, weight float64, reps int) models.WorkoutEntry {
+	t.Helper()
+	entry := createTestEntry(t, db, exercise, date, weight, reps)
+	records, err := DetectPRs(entry, MeasurementType(exercise))
+	if err != nil {
+		t.Fatalf("DetectPRs: %v", err)
+	}
+	if err := RecordPRs(entry, records); err != nil {
+		t.Fatalf("RecordPRs: %v", err)
+	}
+	entry.IsPR = len(BrokenRecords(records)) > 0
+	if err := db.Model(&entry).UpdateColumn("is_pr", entry.IsPR).Error; err != nil {
+		t.Fatalf("flag PR: %v", err)
+	}
+	return entry
+}
+
+func TestReplayPRHistoryDisplacesLaterRecords(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "BP")
+
+	day := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	first := logTestEntry(t, db, exercise, day, 80, 5)
+	later := logTestEntry(t, db, exercise, day.AddDate(0, 0, 10), 100, 5)
+	if first.IsPR || !later.IsPR {
+		t.Fatalf("is_pr = %v, %v before the backdated entry, want false, true", first.IsPR, later.IsPR)
+	}
+
+	backdated := createTestEntry(t, db, exercise, day.AddDate(0, 0, 5), 110, 5)
+	isBackdated, err := IsBackdated(backdated)
+	if err != nil {
+		t.Fatalf("IsBackdated: %v", err)
+	}
+	if !isBackdated {
+		t.Fatal("IsBackdated = false, want true")
+	}
+
+	records, displaced, err := ReplayPRHistory(backdated)
+	if err != nil {
+		t.Fatalf("ReplayPRHistory: %v", err)
+	}
+
+	broke := map[string]float64{}
+	for _, record := range records {
+		if record.Type != PRTypeRepMax {
+			broke[record.Type] = record.PreviousBest
+		}
+	}
+	if previous, ok := broke[PRTypeWeight]; !ok || previous != 80 {
+		t.Errorf("backdated entry broke a weight record of %v (found %v), want 80", previous, ok)
+	}
+
+	lost := map[string]bool{}
+	for _, record := range displaced {
+		if record.WorkoutEntryID != later.ID {
+			t.Errorf("displaced a record of entry %d, want only entry %d's", record.WorkoutEntryID, later.ID)
+		}
+		lost[record.Type] = true
+	}
+	for _, prType := range []string{PRTypeScore, PRTypeWeight, PRTypeE1RM, PRTypeVolume} {
+		if !lost[prType] {
+			t.Errorf("entry %d kept its %s record, want it displaced", later.ID, prType)
+		}
+	}
+
+	flags := map[uint]bool{}
+	var entries []models.WorkoutEntry
+	if err := db.Where("exercise_id = ?", exercise.ID).Find(&entries).Error; err != nil {
+		t.Fatalf("load entries: %v", err)
+	}
+	for _, entry := range entries {
+		flags[entry.ID] = entry.IsPR
+	}
+	if flags[first.ID] || !flags[backdated.ID] || flags[later.ID] {
+		t.Errorf("is_pr first, backdated, later = %v, %v, %v, want false, true, false",
+			flags[first.ID], flags[backdated.ID], flags[later.ID])
+	}
+
+	var history int64
+	db.Model(&models.PRHistory{}).Where("workout_entry_id = ?", later.ID).Count(&history)
+	if history != 0 {
+		t.Errorf("entry %d still holds %d PR history rows, want 0", later.ID, history)
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// InitialStockPrice is the listing price of a newly created exercise
+	InitialStockPrice = 100.0
+	// MinStockPrice keeps a ticker from ever being priced at zero
+	MinStockPrice = 1.0
+	// RecentEntryWindow is the number of latest entries that drive the price
+	RecentEntryWindow = 5
+)
+
+// Reasons recorded alongside each price change
+const (
+	PriceReasonEntryCreated   = "entry_created"
+	PriceReasonEntryUpdated   = "entry_updated"
+	PriceReasonEntryDeleted   = "entry_deleted"
+	PriceReasonManualOverride = "manual_override"
+	PriceReasonRescored       = "rescored"
+	PriceReasonImported       = "imported"
+)
+
+// CalculateStockPrice derives a price from an exercise's entry scores, ordered oldest first.
+// The average of the most recent RecentEntryWindow scores is compared against the average
+// of everything before them, so a ticker trades above InitialStockPrice while recent
+// performance beats its own history and below it while performance slips.
+func CalculateStockPrice(scores []float64) float64 {
+	if len(scores) == 0 {
+		return InitialStockPrice
+	}
+
+	recentStart := len(scores) - RecentEntryWindow
+	baseline := scores[:1]
+	recent := scores
+	if recentStart > 0 {
+		baseline = scores[:recentStart]
+		recent = scores[recentStart:]
+	}
+
+	baselineAvg := average(baseline)
+	if baselineAvg <= 0 {
+		return InitialStockPrice
+	}
+
+	price := InitialStockPrice * average(recent) / baselineAvg
+	return math.Max(roundPrice(price), MinStockPrice)
+}
+
+// RepriceExercise recomputes an exercise's stock price from its workout entries, restated
+// for any splits, and records a PriceHistory row when the price moves. A price that leaves
+// the readable range triggers an automatic split. It returns the resulting stock price.
+func RepriceExercise(exerciseID uint, reason string) (float64, error) {
+	db := database.GetDB()
+
+	var exercise models.Exercise
+	if err := db.First(&exercise, exerciseID).Error; err != nil {
+		return 0, err
+	}
+
+	var scores []float64
+	err := db.Model(&models.WorkoutEntry{}).
+		Where("exercise_id = ?", exerciseID).
+		Order("date ASC, id ASC").
+		Pluck("score", &scores).Error
+	if err != nil {
+		return 0, err
+	}
+
+	factor, err := GetSplitFactor(exerciseID, time.Time{}, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	price := math.Max(roundPrice(CalculateStockPrice(scores)/factor), MinStockPrice)
+	change, err := setStockPrice(&exercise, price, reason)
+	if err != nil || change == nil {
+		return exercise.StockPrice, err
+	}
+
+	if _, err := ApplyAutomaticSplit(&exercise); err != nil {
+		return exercise.StockPrice, err
+	}
+
+	return exercise.StockPrice, nil
+}
+
+// OverrideStockPrice manually sets an exercise's price, bypassing the pricing engine.
+// The next workout entry for the exercise reprices it from performance again; until then
+// the exercise cannot be traded.
+func OverrideStockPrice(exercise *models.Exercise, price float64) (*models.PriceHistory, error) {
+	return setStockPrice(exercise, math.Max(roundPrice(price), MinStockPrice), PriceReasonManualOverride)
+}
+
+// GetPriceHistory returns the price changes for an exercise, oldest first. When adjusted is
+// true, prices from before each split are restated on the post-split scale and the split
+// rows themselves are left out, so the series has no artificial jumps.
+func GetPriceHistory(userID uint, exerciseID uint, adjusted bool) ([]models.PriceHistory, error) {
+	db := database.GetDB()
+	var history []models.PriceHistory
+
+	err := db.Where("user_id = ? AND exercise_id = ?", userID, exerciseID).
+		Order("recorded_at ASC, id ASC").
+		Find(&history).Error
+	if err != nil || !adjusted {
+		return history, err
+	}
+
+	actions, err := GetCorporateActions(userID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustedHistory := make([]models.PriceHistory, 0, len(history))
+	for _, change := range history {
+		if change.Reason == CorporateActionSplit || change.Reason == CorporateActionReverseSplit {
+			continue
+		}
+		factor := 1.0
+		for _, action := range actions {
+			if action.EffectiveAt.After(change.RecordedAt) {
+				factor *= action.Ratio
+			}
+		}
+		change.Price = roundPrice(change.Price / factor)
+		change.PreviousPrice = roundPrice(change.PreviousPrice / factor)
+		adjustedHistory = append(adjustedHistory, change)
+	}
+
+	return adjustedHistory, nil
+}
+
+// GetStockPriceAt returns an exercise's price as it stood at the given time
+func GetStockPriceAt(exercise models.Exercise, at time.Time) (float64, error) {
+	db := database.GetDB()
+	at = at.UTC()
+
+	var change models.PriceHistory
+	err := db.Where("exercise_id = ? AND recorded_at <= ?", exercise.ID, at).
+		Order("recorded_at DESC, id DESC").
+		First(&change).Error
+	if err == nil {
+		return change.Price, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	// Nothing had moved the price yet, so it was whatever the first later change moved it from
+	err = db.Where("exercise_id = ? AND recorded_at > ?", exercise.ID, at).
+		Order("recorded_at ASC, id ASC").
+		First(&change).Error
+	if err == nil {
+		return change.PreviousPrice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	return exercise.StockPrice, nil
+}
+
+// GetListingPrice returns the price an exercise started trading at
+func GetListingPrice(exercise models.Exercise) (float64, error) {
+	var first models.PriceHistory
+	err := database.GetDB().
+		Where("exercise_id = ?", exercise.ID).
+		Order("recorded_at ASC, id ASC").
+		First(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return exercise.StockPrice, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return first.PreviousPrice, nil
+}
+
+func setStockPrice(exercise *models.Exercise, price float64, reason string) (*models.PriceHistory, error) {
+	db := database.GetDB()
+	if price == exercise.StockPrice {
+		// A reprice landing on an overridden price still records a change, to end the halt
+		if reason == PriceReasonManualOverride {
+			return nil, nil
+		}
+		if overridden, err := priceOverridden(db, exercise.ID); err != nil || !overridden {
+			return nil, err
+		}
+	}
+
+	change := models.PriceHistory{
+		UserID:        exercise.UserID,
+		ExerciseID:    exercise.ID,
+		Price:         price,
+		PreviousPrice: exercise.StockPrice,
+		Reason:        reason,
+		RecordedAt:    time.Now(),
+	}
+
+	if err := db.Model(exercise).UpdateColumn("stock_price", price).Error; err != nil {
+		return nil, err
+	}
+	exercise.StockPrice = price
+	if err := db.Create(&change).Error; err != nil {
+		return nil, err
+	}
+
+	// A failed alert check should not undo the price change
+	if err := EvaluatePriceAlerts(exercise, change.PreviousPrice, price); err != nil {
+		log.Printf("Failed to evaluate price alerts for exercise %d: %v", exercise.ID, err)
+	}
+
+	return &change, nil
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+func TestCalculateStockPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []float64
+		want   float64
+	}{
+		{"no entries", nil, InitialStockPrice},
+		{"first entry", []float64{50}, InitialStockPrice},
+		{"improving within the window", []float64{50, 60}, 110},
+		{"recent beats history", []float64{50, 50, 60, 60, 60, 60, 60}, 120},
+		{"recent slips below history", []float64{100, 100, 80, 80, 80, 80, 80}, 80},
+		{"zero history", []float64{0, 10}, InitialStockPrice},
+		{"collapse is floored", []float64{1000, 1000, 1, 1, 1, 1, 1}, MinStockPrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateStockPrice(tt.scores); got != tt.want {
+				t.Errorf("CalculateStockPrice(%v) = %v, want %v", tt.scores, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepriceExerciseRecordsHistory(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "SQ")
+
+	day := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	first := createTestEntry(t, db, exercise, day, 100, 5)
+	second := createTestEntry(t, db, exercise, day.AddDate(0, 0, 2), 120, 5)
+	want := CalculateStockPrice([]float64{first.Score, second.Score})
+	if want <= InitialStockPrice {
+		t.Fatalf("a heavier second entry priced at %v, want above %v", want, InitialStockPrice)
+	}
+
+	price, err := RepriceExercise(exercise.ID, PriceReasonEntryCreated)
+	if err != nil {
+		t.Fatalf("RepriceExercise: %v", err)
+	}
+	if price != want {
+		t.Errorf("RepriceExercise = %v, want %v", price, want)
+	}
+
+	// Repricing to the same price records nothing
+	if _, err := RepriceExercise(exercise.ID, PriceReasonEntryUpdated); err != nil {
+		t.Fatalf("RepriceExercise again: %v", err)
+	}
+
+	var history []models.PriceHistory
+	if err := db.Where("exercise_id = ?", exercise.ID).Find(&history).Error; err != nil {
+		t.Fatalf("load price history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("recorded %d price changes, want 1", len(history))
+	}
+	if history[0].PreviousPrice != 100 || history[0].Price != want || history[0].Reason != PriceReasonEntryCreated {
+		t.Errorf("recorded %v -> %v (%s), want 100 -> %v (%s)",
+			history[0].PreviousPrice, history[0].Price, history[0].Reason, want, PriceReasonEntryCreated)
+	}
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Rescore job statuses
+const (
+	RescoreStatusPending   = "pending"
+	RescoreStatusRunning   = "running"
+	RescoreStatusCompleted = "completed"
+	RescoreStatusFailed    = "failed"
+)
+
+// Reasons a rescore job is started
+const (
+	RescoreReasonBodyweight = "bodyweight"
+	RescoreReasonProfile    = "profile"
+	RescoreReasonStrategy   = "scoring_strategy"
+)
+
+// rescoreLocks holds a mutex per user, so that a user's rescore jobs run one at a time
+var rescoreLocks sync.Map
+
+// RescoreExercise recomputes the score of every entry on an exercise with its current
+// strategy, rebuilds its PR history from the new scores and reprices it
+func RescoreExercise(exerciseID uint) error {
+	db := database.GetDB()
+
+	var exercise models.Exercise
+	if err := db.First(&exercise, exerciseID).Error; err != nil {
+		return err
+	}
+
+	var entries []models.WorkoutEntry
+	if err := preloadSets(db).Where("exercise_id = ?", exerciseID).Find(&entries).Error; err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			score, err := ScoreEntry(exercise, EntrySets(entry), entry.Date, entry.SessionID)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&entry).UpdateColumns(map[string]interface{}{
+				"score":            score.Value,
+				"scoring_strategy": score.Strategy,
+				"scoring_version":  score.Version,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return rebuildPRHistory(tx, exerciseID)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = RepriceExercise(exerciseID, PriceReasonRescored)
+	return err
+}
+
+// StartUserRescore starts a job rescoring all of the user's exercises, after a profile
+// change that affects scoring. It returns nil when the user has no exercises.
+func StartUserRescore(userID uint) (*models.RescoreJob, error) {
+	var exerciseIDs []uint
+	err := database.GetDB().Model(&models.Exercise{}).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Pluck("id", &exerciseIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return StartRescoreJob(userID, RescoreReasonProfile, exerciseIDs)
+}
+
+// RebuildPRHistory replaces an exercise's PR history by replaying its entries oldest first,
+// recording every record each entry broke at the time
+func RebuildPRHistory(exerciseID uint) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return rebuildPRHistory(tx, exerciseID)
+	})
+}
+
+func rebuildPRHistory(tx *gorm.DB, exerciseID uint) error {
+	_, err := replayPRHistory(tx, exerciseID)
+	return err
+}
+
+// replayPRHistory rebuilds an exercise's PR history and IsPR flags and returns the records
+// each entry broke, keyed by entry ID
+func replayPRHistory(tx *gorm.DB, exerciseID uint) (map[uint][]PRRecord, error) {
+	var exercise models.Exercise
+	if err := tx.First(&exercise, exerciseID).Error; err != nil {
+		return nil, err
+	}
+	measurementType := MeasurementType(exercise)
+
+	if err := tx.Unscoped().Where("exercise_id = ?", exerciseID).Delete(&models.PRHistory{}).Error; err != nil {
+		return nil, err
+	}
+
+	var entries []models.WorkoutEntry
+	if err := preloadSets(tx).Where("exercise_id = ?", exerciseID).Order("date ASC, id ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	bests := map[prKey]float64{}
+	broken := map[uint][]PRRecord{}
+	for _, entry := range entries {
+		records := compareRecords(entryRecordValues(entry, measurementType), bests)
+		if err := recordPRs(tx, entry, records); err != nil {
+			return nil, err
+		}
+		broken[entry.ID] = BrokenRecords(records)
+		isPR := len(broken[entry.ID]) > 0
+		if isPR != entry.IsPR {
+			if err := tx.Model(&entry).UpdateColumn("is_pr", isPR).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return broken, nil
+}
+
+// StartRescoreJob records a job to rescore some of a user's exercises and runs it in the
+// background, returning the job as created. It returns nil when there is nothing to rescore.
+func StartRescoreJob(userID uint, reason string, exerciseIDs []uint) (*models.RescoreJob, error) {
+	if len(exerciseIDs) == 0 {
+		return nil, nil
+	}
+
+	job := &models.RescoreJob{
+		UserID:      userID,
+		Reason:      reason,
+		Status:      RescoreStatusPending,
+		ExerciseIDs: exerciseIDs,
+	}
+	if err := database.GetDB().Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	// The job is returned as it was created; the background run updates its own copy
+	created := *job
+	go runRescoreJob(job)
+	return &created, nil
+}
+
+// ResumeRescoreJobs restarts the rescore jobs a previous run of the server left pending or
+// running. Rescoring an exercise again is harmless, so they start over.
+func ResumeRescoreJobs() error {
+	var jobs []models.RescoreJob
+	err := database.GetDB().
+		Where("status IN ?", []string{RescoreStatusPending, RescoreStatusRunning}).
+		Order("id ASC").
+		Find(&jobs).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		jobs[i].RescoredCount = 0
+		go runRescoreJob(&jobs[i])
+	}
+	if len(jobs) > 0 {
+		log.Printf("Resumed %d interrupted rescore jobs", len(jobs))
+	}
+	return nil
+}
+
+// GetRescoreJobs returns the user's rescore jobs, most recent first
+func GetRescoreJobs(userID uint, limit int) ([]models.RescoreJob, error) {
+	var jobs []models.RescoreJob
+	err := database.GetDB().
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// GetRescoreJob returns one of the user's rescore jobs
+func GetRescoreJob(userID uint, jobID uint) (*models.RescoreJob, error) {
+	var job models.RescoreJob
+	if err := database.GetDB().Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// runRescoreJob rescores a job's exercises one by one, skipping any deleted since it was
+// started, once the user's earlier jobs are done
+func runRescoreJob(job *models.RescoreJob) {
+	lock, _ := rescoreLocks.LoadOrStore(job.UserID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Rescore job %d panicked: %v", job.ID, r)
+			finishRescoreJob(job, fmt.Errorf("rescore stopped unexpectedly"))
+		}
+	}()
+
+	db := database.GetDB()
+	now := time.Now()
+	job.Status = RescoreStatusRunning
+	job.StartedAt = &now
+	db.Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": now, "rescored_count": 0})
+
+	for _, exerciseID := range job.ExerciseIDs {
+		if err := RescoreExercise(exerciseID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			finishRescoreJob(job, err)
+			return
+		}
+		job.RescoredCount++
+		db.Model(job).UpdateColumn("rescored_count", job.RescoredCount)
+	}
+	finishRescoreJob(job, nil)
+}
+
+// finishRescoreJob marks a job completed, or failed with err
+func finishRescoreJob(job *models.RescoreJob, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = RescoreStatusCompleted
+	if err != nil {
+		job.Status = RescoreStatusFailed
+		job.Failure = err.Error()
+	}
+	if err := database.GetDB().Save(job).Error; err != nil {
+		log.Printf("Failed to save rescore job %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+)
+
+// waitForRescoreJob polls a rescore job until it finishes
+func waitForRescoreJob(t *testing.T, job *models.RescoreJob) *models.RescoreJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		current, err := GetRescoreJob(job.UserID, job.ID)
+		if err != nil {
+			t.Fatalf("GetRescoreJob: %v", err)
+		}
+		if current.Status == RescoreStatusCompleted || current.Status == RescoreStatusFailed {
+			return current
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("rescore job %d did not finish", job.ID)
+	return nil
+}
+
+func TestStartUserRescore(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	bench := createTestExercise(t, db, user.ID, "BP")
+	squat := createTestExercise(t, db, user.ID, "SQ")
+
+	day := time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC)
+	createTestEntry(t, db, bench, day, 100, 5)
+	createTestEntry(t, db, squat, day, 140, 3)
+
+	if err := db.Create(&models.UserProfile{UserID: user.ID, ScoringStrategy: "e1rm_epley"}).Error; err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	job, err := StartUserRescore(user.ID)
+	if err != nil {
+		t.Fatalf("StartUserRescore: %v", err)
+	}
+	if job == nil || job.Reason != RescoreReasonProfile || len(job.ExerciseIDs) != 2 {
+		t.Fatalf("StartUserRescore = %+v, want a profile job for 2 exercises", job)
+	}
+
+	finished := waitForRescoreJob(t, job)
+	if finished.Status != RescoreStatusCompleted || finished.RescoredCount != 2 {
+		t.Fatalf("job %s with %d rescored (%s), want completed with 2", finished.Status, finished.RescoredCount, finished.Failure)
+	}
+	var entries []models.WorkoutEntry
+	if err := db.Where("user_id = ?", user.ID).Find(&entries).Error; err != nil {
+		t.Fatalf("load entries: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.ScoringStrategy != "e1rm_epley" {
+			t.Errorf("entry %d scored with %s, want e1rm_epley", entry.ID, entry.ScoringStrategy)
+		}
+	}
+
+	// Nothing to rescore starts no job
+	other := createTestUser(t, db)
+	if job, err := StartUserRescore(other.ID); err != nil || job != nil {
+		t.Errorf("StartUserRescore without exercises = %v, %v, want nil, nil", job, err)
+	}
+}
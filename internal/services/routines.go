@@ -0,0 +1,273 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmptyRoutine                 = errors.New("a routine needs at least one exercise")
+	ErrRoutineExerciseNotFound      = errors.New("exercise not found")
+	ErrConflictingRoutineTargets    = errors.New("give target_weight or target_percentage, not both")
+	ErrRoutinePercentageUnsupported = errors.New("target_percentage is only available for weight_reps exercises")
+	ErrRoutineWeightUnsupported     = errors.New("target_weight is only available for exercises measured by weight")
+	ErrRoutineRepsUnsupported       = errors.New("target_reps is not available for timed or distance exercises")
+)
+
+// RoutineWarningNoOneRepMax flags a draft whose target_percentage could not be applied
+// because the exercise has no recent e1RM to work the weight out from
+const RoutineWarningNoOneRepMax = "target_percentage ignored: no recent e1RM for this exercise"
+
+// RoutineDraft is an entry pre-filled for one exercise of a started routine. Sets are the
+// routine's targets, filled in from Previous, the exercise's latest entry, where the routine
+// leaves them open. Warning says why a target could not be applied, if one couldn't.
+type RoutineDraft struct {
+	RoutineExercise models.RoutineExercise
+	Sets            []models.WorkoutSet
+	Previous        *models.WorkoutEntry
+	Warning         string
+}
+
+// RoutineStart is the session a routine was started as, with a draft entry for each of its
+// exercises in order
+type RoutineStart struct {
+	Session models.WorkoutSession
+	Drafts  []RoutineDraft
+}
+
+// preloadRoutineExercises loads routines' exercises in order
+func preloadRoutineExercises(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Exercises", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Exercises.Exercise")
+}
+
+// GetRoutines returns the user's routines with their exercises
+func GetRoutines(userID uint) ([]models.Routine, error) {
+	var routines []models.Routine
+	err := preloadRoutineExercises(database.GetDB()).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&routines).Error
+
+	return routines, err
+}
+
+// GetRoutine returns one of the user's routines with its exercises
+func GetRoutine(userID uint, routineID uint) (*models.Routine, error) {
+	var routine models.Routine
+	err := preloadRoutineExercises(database.GetDB()).
+		Where("id = ? AND user_id = ?", routineID, userID).
+		First(&routine).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &routine, nil
+}
+
+// CreateRoutine saves a new routine with its exercises, in the order given
+func CreateRoutine(routine *models.Routine) error {
+	db := database.GetDB()
+	if err := validateRoutineExercises(db, routine.UserID, routine.Exercises); err != nil {
+		return err
+	}
+	if err := db.Create(routine).Error; err != nil {
+		return err
+	}
+
+	created, err := GetRoutine(routine.UserID, routine.ID)
+	if err != nil {
+		return err
+	}
+	*routine = *created
+	return nil
+}
+
+// UpdateRoutine saves changes to a routine. When exercises is not nil it replaces the
+// routine's exercises, in the order given.
+func UpdateRoutine(routine *models.Routine, exercises []models.RoutineExercise) error {
+	db := database.GetDB()
+	if exercises != nil {
+		if err := validateRoutineExercises(db, routine.UserID, exercises); err != nil {
+			return err
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if exercises != nil {
+			if err := tx.Where("routine_id = ?", routine.ID).Delete(&models.RoutineExercise{}).Error; err != nil {
+				return err
+			}
+			for i := range exercises {
+				exercises[i].RoutineID = routine.ID
+			}
+			if err := tx.Omit("Exercise").Create(&exercises).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("User", "Exercises").Save(routine).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	updated, err := GetRoutine(routine.UserID, routine.ID)
+	if err != nil {
+		return err
+	}
+	*routine = *updated
+	return nil
+}
+
+// DeleteRoutine removes one of the user's routines along with its exercises. Sessions
+// started from it are kept.
+func DeleteRoutine(userID uint, routineID uint) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var routine models.Routine
+		if err := tx.Where("id = ? AND user_id = ?", routineID, userID).First(&routine).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("routine_id = ?", routine.ID).Delete(&models.RoutineExercise{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&routine).Error
+	})
+}
+
+// validateRoutineExercises checks each exercise of a routine is one of the user's and that
+// its targets suit it, numbering them in order
+func validateRoutineExercises(db *gorm.DB, userID uint, exercises []models.RoutineExercise) error {
+	if len(exercises) == 0 {
+		return ErrEmptyRoutine
+	}
+
+	for i := range exercises {
+		item := &exercises[i]
+		item.ID = 0
+		item.Position = i + 1
+
+		var exercise models.Exercise
+		if err := db.Where("id = ? AND user_id = ?", item.ExerciseID, userID).First(&exercise).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("exercise %d: %w", item.Position, ErrRoutineExerciseNotFound)
+			}
+			return err
+		}
+		if item.TargetWeight != nil && item.TargetPercentage != nil {
+			return fmt.Errorf("exercise %d: %w", item.Position, ErrConflictingRoutineTargets)
+		}
+		measurementType := MeasurementType(exercise)
+		if item.TargetPercentage != nil && measurementType != MeasurementWeightReps {
+			return fmt.Errorf("exercise %d: %w", item.Position, ErrRoutinePercentageUnsupported)
+		}
+		if item.TargetWeight != nil && !usesWeight(measurementType) {
+			return fmt.Errorf("exercise %d: %w", item.Position, ErrRoutineWeightUnsupported)
+		}
+		if item.TargetReps > 0 && (measurementType == MeasurementTime || measurementType == MeasurementDistanceTime) {
+			return fmt.Errorf("exercise %d: %w", item.Position, ErrRoutineRepsUnsupported)
+		}
+	}
+	return nil
+}
+
+// StartRoutine starts a session from one of the user's routines, titled after the routine
+// unless session has a title, and drafts an entry for each of the routine's exercises.
+// Exercises deleted since the routine was saved are skipped. The drafts are not saved;
+// they are logged through POST /entries once performed.
+func StartRoutine(userID uint, routineID uint, session *models.WorkoutSession) (*RoutineStart, error) {
+	routine, err := GetRoutine(userID, routineID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.GetDB()
+	increment := plateIncrement(userID)
+	drafts := make([]RoutineDraft, 0, len(routine.Exercises))
+	for _, item := range routine.Exercises {
+		if item.Exercise.ID == 0 {
+			continue
+		}
+		draft, err := draftRoutineExercise(db, item, increment)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+
+	session.UserID = userID
+	if session.Title == "" {
+		session.Title = routine.Name
+	}
+	if err := StartSession(session); err != nil {
+		return nil, err
+	}
+
+	return &RoutineStart{Session: *session, Drafts: drafts}, nil
+}
+
+// draftRoutineExercise pre-fills the sets of one exercise of a routine. Each target the
+// routine sets wins; the rest come from the matching working set of the latest entry, the
+// last one repeated when the routine asks for more sets than were done.
+func draftRoutineExercise(db *gorm.DB, item models.RoutineExercise, increment float64) (RoutineDraft, error) {
+	draft := RoutineDraft{RoutineExercise: item, Sets: []models.WorkoutSet{}}
+
+	var latest models.WorkoutEntry
+	if err := preloadSets(db).Preload("Exercise").Where("exercise_id = ?", item.ExerciseID).Order("date DESC, id DESC").Limit(1).Find(&latest).Error; err != nil {
+		return draft, err
+	}
+	var previous []models.WorkoutSet
+	if latest.ID != 0 {
+		draft.Previous = &latest
+		previous = WorkingSets(EntrySets(latest))
+	}
+
+	var weight *float64
+	switch {
+	case item.TargetWeight != nil:
+		weight = item.TargetWeight
+	case item.TargetPercentage != nil:
+		best, err := recentOneRepMax(db, item.ExerciseID)
+		if err != nil {
+			return draft, err
+		}
+		if best.Value > 0 {
+			load := roundToPlate(best.Value**item.TargetPercentage/100, increment)
+			weight = &load
+		} else {
+			draft.Warning = RoutineWarningNoOneRepMax
+		}
+	}
+
+	count := item.TargetSets
+	if count == 0 {
+		count = len(previous)
+	}
+	if count == 0 && (item.TargetReps > 0 || weight != nil) {
+		count = 1
+	}
+
+	for i := 0; i < count; i++ {
+		set := models.WorkoutSet{Type: SetTypeWorking}
+		if len(previous) > 0 {
+			last := previous[min(i, len(previous)-1)]
+			set.Type, set.Weight, set.Reps = last.Type, last.Weight, last.Reps
+			set.Duration, set.Distance = last.Duration, last.Distance
+			set.RPE, set.RIR = last.RPE, last.RIR
+		}
+		if item.TargetReps > 0 {
+			set.Reps = item.TargetReps
+		}
+		if weight != nil {
+			set.Weight = *weight
+		}
+		set.SetIndex = i + 1
+		draft.Sets = append(draft.Sets, set)
+	}
+	return draft, nil
+}
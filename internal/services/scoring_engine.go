@@ -1,83 +1,79 @@
 package services
 
 import (
+	"time"
+
 	"fitness-market/internal/database"
 	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
 )
 
-// CalculateEntryScore calculates the score for a workout entry
-// Score formula: weight * reps * sets * bodyweight_factor
-func CalculateEntryScore(weight float64, reps int, sets int, userID uint) float64 {
-	if weight <= 0 || reps <= 0 || sets <= 0 {
-		return 0
-	}
-
-	rawScore := weight * float64(reps) * float64(sets)
-	normalizedScore := NormalizeScore(rawScore, userID)
-
-	return normalizedScore
+// EntryScore is a workout entry's score with the strategy and version that produced it
+type EntryScore struct {
+	Value    float64 `json:"score"`
+	Strategy string  `json:"strategy"`
+	Version  int     `json:"version"`
 }
 
-// DetectPR checks if the current entry is a personal record for the exercise
-func DetectPR(userID uint, exerciseID uint, weight float64, reps int) bool {
-	if weight <= 0 || reps <= 0 {
-		return false
-	}
+// ResolveScoringStrategy returns the strategy that scores an exercise's entries: the
+// exercise's own choice, else the user's profile default, else scoring.Default
+func ResolveScoringStrategy(exercise models.Exercise) string {
+	return resolveScoringStrategy(exercise, getScoringProfile(exercise.UserID))
+}
 
-	var existingPR models.ExercisePR
-	var exercise models.Exercise
+// ScoreEntry scores the working sets of an entry on an exercise, using the user's sex and
+// their bodyweight for the entry, see GetEntryBodyweight. Load-based exercises are scored
+// with the resolved strategy on the effective load; reps-only, timed and distance exercises
+// have a fixed scorer of their own.
+func ScoreEntry(exercise models.Exercise, sets []models.WorkoutSet, date time.Time, sessionID *uint) (EntryScore, error) {
+	profile := getScoringProfile(exercise.UserID)
+	measurementType := MeasurementType(exercise)
+	bodyweight := GetEntryBodyweight(exercise.UserID, sessionID, date)
 
-	// Get exercise name
-	if err := database.DB.First(&exercise, exerciseID).Error; err != nil {
-		return false
+	scorer := measurementScorer(measurementType)
+	if scorer == nil {
+		var err error
+		scorer, err = scoring.Get(resolveScoringStrategy(exercise, profile))
+		if err != nil {
+			return EntryScore{}, err
+		}
 	}
 
-	// Check if there's an existing PR for this exercise
-	err := database.DB.Where("user_id = ? AND exercise_name = ?", userID, exercise.Name).First(&existingPR).Error
-
-	if err != nil {
-		// No existing PR, this is a new PR
-		return true
+	working := WorkingSets(sets)
+	inputs := make([]scoring.Input, 0, len(working))
+	for _, set := range working {
+		inputs = append(inputs, scoring.Input{
+			Weight:     effectiveLoad(measurementType, set.Weight, bodyweight),
+			Reps:       set.Reps,
+			Sets:       1,
+			Duration:   set.Duration,
+			Distance:   set.Distance,
+			Bodyweight: bodyweight,
+			Sex:        profile.Sex,
+		})
 	}
+	score := scoring.ScoreSets(scorer, inputs)
 
-	// Compare: PR if weight is higher at same or more reps, or same weight with more reps
-	if weight > existingPR.Weight {
-		return true
-	}
-	if weight == existingPR.Weight && reps > existingPR.Reps {
-		return true
-	}
+	return EntryScore{
+		Value:    score,
+		Strategy: scorer.Name(),
+		Version:  scorer.Version(),
+	}, nil
+}
 
-	return false
+// getScoringProfile returns the user's profile, or an empty one if they have none yet
+func getScoringProfile(userID uint) models.UserProfile {
+	var profile models.UserProfile
+	database.DB.Where("user_id = ?", userID).First(&profile)
+	return profile
 }
 
-// UpdatePRIfNeeded updates the PR record if the entry is a new PR
-func UpdatePRIfNeeded(userID uint, exerciseID uint, weight float64, reps int, date interface{}) error {
-	var exercise models.Exercise
-	if err := database.DB.First(&exercise, exerciseID).Error; err != nil {
-		return err
+func resolveScoringStrategy(exercise models.Exercise, profile models.UserProfile) string {
+	if exercise.ScoringStrategy != "" {
+		return exercise.ScoringStrategy
 	}
-
-	var existingPR models.ExercisePR
-	err := database.DB.Where("user_id = ? AND exercise_name = ?", userID, exercise.Name).First(&existingPR).Error
-
-	if err != nil {
-		// Create new PR
-		newPR := models.ExercisePR{
-			UserID:       userID,
-			ExerciseName: exercise.Name,
-			Weight:       weight,
-			Reps:         reps,
-			Unit:         "kg",
-		}
-		if t, ok := date.(interface{ Unix() int64 }); ok {
-			_ = t
-		}
-		return database.DB.Create(&newPR).Error
+	if profile.ScoringStrategy != "" {
+		return profile.ScoringStrategy
 	}
-
-	// Update existing PR
-	existingPR.Weight = weight
-	existingPR.Reps = reps
-	return database.DB.Save(&existingPR).Error
+	return scoring.Default
 }
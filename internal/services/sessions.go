@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionFinished   = errors.New("session is already finished")
+	ErrInvalidSessionEnd = errors.New("ended_at cannot be before the session started")
+)
+
+// SessionTotals sums up what was done in a session. Volume is weight x reps over the working
+// sets and Duration is in seconds, up to now for a session still in progress.
+type SessionTotals struct {
+	EntryCount int     `json:"entry_count"`
+	SetCount   int     `json:"set_count"`
+	Volume     float64 `json:"volume"`
+	Duration   int     `json:"duration"`
+	PRCount    int     `json:"pr_count"`
+}
+
+// SessionSummary is a session with its totals
+type SessionSummary struct {
+	models.WorkoutSession
+	Totals SessionTotals `json:"totals"`
+}
+
+// StartSession starts a workout session for the user, now unless StartedAt is set
+func StartSession(session *models.WorkoutSession) error {
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now()
+	}
+	return database.GetDB().Create(session).Error
+}
+
+// GetSessions returns the user's sessions with their totals, most recent first
+func GetSessions(userID uint, limit int) ([]SessionSummary, error) {
+	var sessions []models.WorkoutSession
+	err := database.GetDB().
+		Where("user_id = ?", userID).
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]SessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		totals, err := GetSessionTotals(session)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, SessionSummary{WorkoutSession: session, Totals: totals})
+	}
+	return summaries, nil
+}
+
+// GetSession returns one of the user's sessions with its entries, their sets and its totals
+func GetSession(userID uint, sessionID uint) (*SessionSummary, error) {
+	var session models.WorkoutSession
+	err := database.GetDB().
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return preloadSets(db).Order("date ASC, id ASC") }).
+		Preload("Entries.Exercise").
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := GetSessionTotals(session)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionSummary{WorkoutSession: session, Totals: totals}, nil
+}
+
+// FinishSession ends one of the user's sessions at endedAt, now if zero
+func FinishSession(userID uint, sessionID uint, endedAt time.Time, notes *string) (*SessionSummary, error) {
+	db := database.GetDB()
+
+	var session models.WorkoutSession
+	if err := db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, ErrSessionFinished
+	}
+
+	if endedAt.IsZero() {
+		endedAt = time.Now()
+	}
+	if endedAt.Before(session.StartedAt) {
+		return nil, ErrInvalidSessionEnd
+	}
+
+	updates := map[string]interface{}{"ended_at": endedAt}
+	if notes != nil {
+		updates["notes"] = *notes
+	}
+	if err := db.Model(&session).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return GetSession(userID, sessionID)
+}
+
+// GetSessionTotals sums up a session's entries, their working sets and how many of the
+// entries set a PR
+func GetSessionTotals(session models.WorkoutSession) (SessionTotals, error) {
+	db := database.GetDB()
+	totals := SessionTotals{}
+
+	var entries []models.WorkoutEntry
+	if err := preloadSets(db).Where("session_id = ?", session.ID).Find(&entries).Error; err != nil {
+		return totals, err
+	}
+
+	totals.EntryCount = len(entries)
+	for _, entry := range entries {
+		for _, set := range WorkingSets(EntrySets(entry)) {
+			totals.SetCount++
+			totals.Volume += set.Weight * float64(set.Reps)
+		}
+		if entry.IsPR {
+			totals.PRCount++
+		}
+	}
+
+	end := time.Now()
+	if session.EndedAt != nil {
+		end = *session.EndedAt
+	}
+	totals.Duration = int(end.Sub(session.StartedAt).Seconds())
+
+	return totals, nil
+}
+
+// CountWorkouts counts the user's workouts before a time: each session is one workout, and
+// entries logged outside a session count once per day, in the user's time zone, they were
+// logged on
+func CountWorkouts(userID uint, before time.Time) (int64, error) {
+	db := database.GetDB()
+	before = before.UTC()
+
+	var sessions int64
+	err := db.Model(&models.WorkoutSession{}).
+		Where("user_id = ? AND started_at < ?", userID, before).
+		Count(&sessions).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var dates []time.Time
+	err = db.Model(&models.WorkoutEntry{}).
+		Where("user_id = ? AND session_id IS NULL AND date < ?", userID, before).
+		Pluck("date", &dates).Error
+	if err != nil {
+		return 0, err
+	}
+
+	loc := GetUserLocation(userID)
+	standaloneDays := map[time.Time]bool{}
+	for _, date := range dates {
+		standaloneDays[CalendarDate(date, loc)] = true
+	}
+
+	return sessions + int64(len(standaloneDays)), nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPlateIncrement is the smallest load jump, in kilograms, for users who haven't set one
+	DefaultPlateIncrement = 2.5
+	// SuggestionLookbackDays is how far before the latest entry sets are considered for the e1RM
+	SuggestionLookbackDays = 28
+)
+
+var (
+	ErrSuggestionUnsupported = errors.New("load suggestions are only available for weight_reps exercises")
+	ErrNoSuggestionHistory   = errors.New("log at least one working set before asking for a suggestion")
+	ErrInvalidSuggestion     = errors.New("target_reps must be between 1 and 12 and target_rpe between 6 and 10")
+)
+
+// OneRepMaxEstimate is an estimated one-rep max and the set it was estimated from
+type OneRepMaxEstimate struct {
+	Value   float64   `json:"value"`
+	EntryID uint      `json:"entry_id"`
+	Date    time.Time `json:"date"`
+	Weight  float64   `json:"weight"`
+	Reps    int       `json:"reps"`
+	RPE     *float64  `json:"rpe"`
+}
+
+// LoadSuggestion is the load recommended for a set of TargetReps at TargetRPE
+type LoadSuggestion struct {
+	ExerciseID     uint              `json:"exercise_id"`
+	TargetReps     int               `json:"target_reps"`
+	TargetRPE      float64           `json:"target_rpe"`
+	Percentage     float64           `json:"percentage"`
+	Weight         float64           `json:"weight"`
+	PlateIncrement float64           `json:"plate_increment"`
+	E1RM           OneRepMaxEstimate `json:"e1rm"`
+}
+
+// EstimateOneRepMax estimates a one-rep max from a set, with the RPE chart when the set or
+// its entry records an effort and with the Epley formula otherwise
+func EstimateOneRepMax(entry models.WorkoutEntry, set models.WorkoutSet) float64 {
+	if rpe, ok := SetRPE(set, entry); ok {
+		return scoring.RPEOneRepMax(set.Weight, set.Reps, rpe)
+	}
+	return scoring.EpleyOneRepMax(set.Weight, set.Reps)
+}
+
+// SuggestLoad recommends the load for a set of targetReps at targetRPE on one of the user's
+// exercises. The e1RM is the best estimate from the working sets logged in the
+// SuggestionLookbackDays up to the latest entry, and the load is rounded to the user's
+// plate increment.
+func SuggestLoad(userID uint, exerciseID uint, targetReps int, targetRPE float64) (*LoadSuggestion, error) {
+	db := database.GetDB()
+
+	var exercise models.Exercise
+	if err := db.Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+		return nil, err
+	}
+	if MeasurementType(exercise) != MeasurementWeightReps {
+		return nil, ErrSuggestionUnsupported
+	}
+
+	percentage, ok := scoring.RPEPercentage(targetReps, targetRPE)
+	if !ok {
+		return nil, ErrInvalidSuggestion
+	}
+
+	best, err := recentOneRepMax(db, exercise.ID)
+	if err != nil {
+		return nil, err
+	}
+	if best.Value <= 0 {
+		return nil, ErrNoSuggestionHistory
+	}
+	increment := plateIncrement(userID)
+
+	return &LoadSuggestion{
+		ExerciseID:     exercise.ID,
+		TargetReps:     targetReps,
+		TargetRPE:      targetRPE,
+		Percentage:     roundPrice(percentage),
+		Weight:         roundToPlate(best.Value*percentage/100, increment),
+		PlateIncrement: increment,
+		E1RM:           best,
+	}, nil
+}
+
+// recentOneRepMax returns the best e1RM estimate from the working sets of an exercise logged
+// in the SuggestionLookbackDays up to its latest entry, or a zero estimate without history
+func recentOneRepMax(db *gorm.DB, exerciseID uint) (OneRepMaxEstimate, error) {
+	var best OneRepMaxEstimate
+
+	var latest models.WorkoutEntry
+	if err := db.Where("exercise_id = ?", exerciseID).Order("date DESC, id DESC").Limit(1).Find(&latest).Error; err != nil {
+		return best, err
+	}
+	if latest.ID == 0 {
+		return best, nil
+	}
+
+	var entries []models.WorkoutEntry
+	err := preloadSets(db).
+		Where("exercise_id = ? AND date >= ?", exerciseID, latest.Date.AddDate(0, 0, -SuggestionLookbackDays).UTC()).
+		Find(&entries).Error
+	if err != nil {
+		return best, err
+	}
+
+	for _, entry := range entries {
+		for _, set := range WorkingSets(EntrySets(entry)) {
+			e1rm := EstimateOneRepMax(entry, set)
+			if e1rm <= best.Value {
+				continue
+			}
+			best = OneRepMaxEstimate{Value: e1rm, EntryID: entry.ID, Date: entry.Date, Weight: set.Weight, Reps: set.Reps}
+			if rpe, ok := SetRPE(set, entry); ok {
+				best.RPE = &rpe
+			}
+		}
+	}
+	best.Value = roundPrice(best.Value)
+	return best, nil
+}
+
+// plateIncrement returns the smallest load jump the user's loads are rounded to
+func plateIncrement(userID uint) float64 {
+	increment := getScoringProfile(userID).PlateIncrement
+	if increment <= 0 {
+		increment = DefaultPlateIncrement
+	}
+	return increment
+}
+
+// roundToPlate rounds a load to the nearest plate increment
+func roundToPlate(weight float64, increment float64) float64 {
+	return roundPrice(math.Round(weight/increment) * increment)
+}
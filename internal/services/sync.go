@@ -0,0 +1,922 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxSyncChanges is the most changes a client can push in one request
+const MaxSyncChanges = 500
+
+// SyncTokenOverlap is how far a sync token reaches back before its pull was taken, so a
+// write that committed while the pull ran is returned again next time rather than missed.
+// Clients upsert pulled records by UUID, so the overlap only repeats records.
+const SyncTokenOverlap = 10 * time.Second
+
+// Record types a client can sync
+const (
+	SyncTypeExercise   = "exercise"
+	SyncTypeEntry      = "entry"
+	SyncTypeBodyweight = "bodyweight"
+)
+
+// Operations a pushed change can make
+const (
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+)
+
+// Outcomes of a pushed change
+const (
+	SyncStatusCreated  = "created"
+	SyncStatusUpdated  = "updated"
+	SyncStatusDeleted  = "deleted"
+	SyncStatusConflict = "conflict"
+	SyncStatusRejected = "rejected"
+	SyncStatusFailed   = "failed"
+)
+
+var (
+	ErrInvalidSyncToken   = errors.New("invalid sync token")
+	ErrTooManySyncChanges = fmt.Errorf("at most %d changes can be pushed at once", MaxSyncChanges)
+)
+
+// syncToken is when a pull was taken, less SyncTokenOverlap; the next pull returns what
+// changed after it
+type syncToken struct {
+	Time int64 `json:"t"`
+}
+
+// SyncRecords are the records of one type that changed since a pull. Updated holds
+// created and updated records; Deleted the UUIDs of records deleted since.
+type SyncRecords[T any] struct {
+	Updated []T      `json:"updated"`
+	Deleted []string `json:"deleted"`
+}
+
+// SyncPRs is the full PR history of every exercise whose records may have changed since
+// a pull. PR history is rebuilt rather than edited, so clients replace what they hold for
+// each of ExerciseIDs with Records.
+type SyncPRs struct {
+	ExerciseIDs []uint             `json:"exercise_ids"`
+	Records     []models.PRHistory `json:"records"`
+}
+
+// SyncPull is everything that changed since a sync token, and the token to pull from next
+type SyncPull struct {
+	Token      string                              `json:"token"`
+	Exercises  SyncRecords[models.Exercise]        `json:"exercises"`
+	Entries    SyncRecords[models.WorkoutEntry]    `json:"entries"`
+	Bodyweight SyncRecords[models.BodyweightEntry] `json:"bodyweight"`
+	PRs        SyncPRs                             `json:"prs"`
+}
+
+// SyncChange is a change a client made offline to the record with the given UUID. Data
+// holds the record's fields for an upsert and UpdatedAt when the client made the change.
+type SyncChange struct {
+	Type      string          `json:"type"`
+	UUID      string          `json:"uuid"`
+	Op        string          `json:"op"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// SyncResult is the outcome of one pushed change. Record is the record as the server now
+// holds it, which for a conflict is the newer server version the client should keep.
+type SyncResult struct {
+	Type   string      `json:"type"`
+	UUID   string      `json:"uuid"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Record interface{} `json:"record,omitempty"`
+}
+
+// SyncExerciseData is the data of an exercise upsert. Empty fields keep their current
+// value on update; on create they are filled in from the catalog when catalog_id is set.
+// On update, catalog_id links the exercise to a catalog entry, or unlinks it when 0.
+type SyncExerciseData struct {
+	CatalogID       *uint   `json:"catalog_id"`
+	Ticker          string  `json:"ticker"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	Category        string  `json:"category"`
+	ScoringStrategy *string `json:"scoring_strategy"`
+	MeasurementType string  `json:"measurement_type"`
+}
+
+// SyncEntryData is the data of an entry upsert. The exercise is given by exercise_uuid,
+// so an entry can follow an exercise created in the same push, or by exercise_id. date
+// (YYYY-MM-DD) defaults to the session's start, or when the change was made.
+type SyncEntryData struct {
+	ExerciseUUID string              `json:"exercise_uuid"`
+	ExerciseID   uint                `json:"exercise_id"`
+	SessionID    *uint               `json:"session_id"`
+	Date         string              `json:"date"`
+	WorkoutSets  []models.WorkoutSet `json:"workout_sets"`
+	RPE          *float64            `json:"rpe"`
+	RIR          *int                `json:"rir"`
+	Notes        string              `json:"notes"`
+}
+
+// SyncBodyweightData is the data of a bodyweight upsert
+type SyncBodyweightData struct {
+	Weight     float64   `json:"weight"`
+	Unit       string    `json:"unit"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// PullChanges returns the user's exercises, entries, bodyweight and PRs that changed since
+// token, or all of them when token is empty. Records changed just before a pull are
+// returned again by the next one, see SyncTokenOverlap.
+func PullChanges(userID uint, token string) (*SyncPull, error) {
+	var since time.Time
+	if token != "" {
+		var err error
+		since, err = decodeSyncToken(token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db := database.GetDB()
+	pull := &SyncPull{Token: encodeSyncToken(time.Now().Add(-SyncTokenOverlap))}
+
+	var err error
+	if pull.Exercises, err = pullRecords[models.Exercise](db, userID, since); err != nil {
+		return nil, err
+	}
+	if err := pullRepricedExercises(db, userID, since, &pull.Exercises); err != nil {
+		return nil, err
+	}
+	if pull.Entries, err = pullRecords[models.WorkoutEntry](db, userID, since, preloadSets); err != nil {
+		return nil, err
+	}
+	if pull.Bodyweight, err = pullRecords[models.BodyweightEntry](db, userID, since); err != nil {
+		return nil, err
+	}
+	if pull.PRs, err = pullPRs(db, userID, since); err != nil {
+		return nil, err
+	}
+	if err := pullRescoredEntries(db, userID, since, pull.PRs.ExerciseIDs, &pull.Entries); err != nil {
+		return nil, err
+	}
+	return pull, nil
+}
+
+// pullRecords returns a user's records of one type updated or deleted after since
+func pullRecords[T any](db *gorm.DB, userID uint, since time.Time, scopes ...func(*gorm.DB) *gorm.DB) (SyncRecords[T], error) {
+	records := SyncRecords[T]{Updated: []T{}, Deleted: []string{}}
+
+	query := db.Scopes(scopes...).Where("user_id = ?", userID)
+	if !since.IsZero() {
+		query = query.Where("updated_at > ?", since.UTC())
+	}
+	if err := query.Order("updated_at ASC, id ASC").Find(&records.Updated).Error; err != nil {
+		return records, err
+	}
+
+	if since.IsZero() {
+		return records, nil
+	}
+	var model T
+	err := db.Unscoped().Model(&model).
+		Where("user_id = ? AND deleted_at > ?", userID, since.UTC()).
+		Order("deleted_at ASC").
+		Pluck("uuid", &records.Deleted).Error
+	return records, err
+}
+
+// pullRepricedExercises adds the exercises repriced after since to those pulled. Repricing
+// doesn't touch UpdatedAt, so a client's offline edit isn't lost to a server-side price change.
+func pullRepricedExercises(db *gorm.DB, userID uint, since time.Time, records *SyncRecords[models.Exercise]) error {
+	if since.IsZero() {
+		return nil
+	}
+	pulled := make([]uint, 0, len(records.Updated))
+	for _, exercise := range records.Updated {
+		pulled = append(pulled, exercise.ID)
+	}
+
+	repriced := db.Model(&models.PriceHistory{}).
+		Select("exercise_id").
+		Where("user_id = ? AND created_at > ?", userID, since.UTC())
+	query := db.Where("user_id = ? AND id IN (?)", userID, repriced)
+	if len(pulled) > 0 {
+		query = query.Where("id NOT IN ?", pulled)
+	}
+	var exercises []models.Exercise
+	if err := query.Order("id ASC").Find(&exercises).Error; err != nil {
+		return err
+	}
+	records.Updated = append(records.Updated, exercises...)
+	return nil
+}
+
+// pullRescoredEntries adds the entries of the exercises whose PR history may have been rebuilt
+// after since to those pulled. Rescoring and PR replay change entries' scores and IsPR flags
+// without touching UpdatedAt, so a client's offline edit isn't lost to a server-side rescore.
+func pullRescoredEntries(db *gorm.DB, userID uint, since time.Time, exerciseIDs []uint, records *SyncRecords[models.WorkoutEntry]) error {
+	if since.IsZero() || len(exerciseIDs) == 0 {
+		return nil
+	}
+	pulled := make([]uint, 0, len(records.Updated))
+	for _, entry := range records.Updated {
+		pulled = append(pulled, entry.ID)
+	}
+
+	query := preloadSets(db).Where("user_id = ? AND exercise_id IN ?", userID, exerciseIDs)
+	if len(pulled) > 0 {
+		query = query.Where("id NOT IN ?", pulled)
+	}
+	var entries []models.WorkoutEntry
+	if err := query.Order("id ASC").Find(&entries).Error; err != nil {
+		return err
+	}
+	records.Updated = append(records.Updated, entries...)
+	return nil
+}
+
+// pullPRs returns the PR history of the exercises whose records may have changed after
+// since: those with new PR history rows, or with entries changed or deleted since, which
+// rebuilds their history
+func pullPRs(db *gorm.DB, userID uint, since time.Time) (SyncPRs, error) {
+	prs := SyncPRs{ExerciseIDs: []uint{}, Records: []models.PRHistory{}}
+
+	exercises := db.Model(&models.Exercise{}).Where("user_id = ?", userID)
+	if !since.IsZero() {
+		changedPRs := db.Model(&models.PRHistory{}).
+			Select("exercise_id").
+			Where("user_id = ? AND updated_at > ?", userID, since.UTC())
+		changedEntries := db.Unscoped().Model(&models.WorkoutEntry{}).
+			Select("exercise_id").
+			Where("user_id = ? AND (updated_at > ? OR deleted_at > ?)", userID, since.UTC(), since.UTC())
+		exercises = exercises.Where("(id IN (?) OR id IN (?))", changedPRs, changedEntries)
+	}
+	if err := exercises.Order("id ASC").Pluck("id", &prs.ExerciseIDs).Error; err != nil {
+		return prs, err
+	}
+	if len(prs.ExerciseIDs) == 0 {
+		return prs, nil
+	}
+
+	err := db.Where("user_id = ? AND exercise_id IN ?", userID, prs.ExerciseIDs).
+		Order("exercise_id ASC, achieved_at ASC, id ASC").
+		Find(&prs.Records).Error
+	return prs, err
+}
+
+// PushChanges applies a batch of changes a client made offline, in order, and reports the
+// outcome of each. Conflicts are resolved last writer wins: a change made before the
+// server's copy was last updated is not applied. A deleted record stays deleted. A change
+// the server fails to apply is reported as failed without stopping the rest; pushing it
+// again is safe, as changes are matched to records by UUID.
+func PushChanges(userID uint, changes []SyncChange) ([]SyncResult, error) {
+	if len(changes) > MaxSyncChanges {
+		return nil, ErrTooManySyncChanges
+	}
+
+	results := make([]SyncResult, 0, len(changes))
+	for _, change := range changes {
+		result := SyncResult{Type: change.Type, UUID: change.UUID}
+		if err := pushChange(userID, change, &result); err != nil {
+			log.Printf("Failed to sync %s %s for user %d: %v", change.Type, change.UUID, userID, err)
+			result.Status = SyncStatusFailed
+			result.Error = "failed to apply change"
+			result.Record = nil
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// pushChange applies one change, recording its outcome in result. Changes that are
+// invalid are rejected; the error is only for failures to apply a valid change.
+func pushChange(userID uint, change SyncChange, result *SyncResult) error {
+	id, err := uuid.Parse(change.UUID)
+	if err != nil {
+		result.reject(errors.New("uuid must be a valid UUID"))
+		return nil
+	}
+	change.UUID = id.String()
+	result.UUID = change.UUID
+
+	if change.Op != SyncOpUpsert && change.Op != SyncOpDelete {
+		result.reject(errors.New("op must be upsert or delete"))
+		return nil
+	}
+	if change.UpdatedAt.IsZero() {
+		result.reject(errors.New("updated_at is required"))
+		return nil
+	}
+
+	db := database.GetDB()
+	switch change.Type {
+	case SyncTypeExercise:
+		return pushExercise(db, userID, change, result)
+	case SyncTypeEntry:
+		return pushEntry(db, userID, change, result)
+	case SyncTypeBodyweight:
+		return pushBodyweight(db, userID, change, result)
+	default:
+		result.reject(errors.New("type must be exercise, entry or bodyweight"))
+		return nil
+	}
+}
+
+func (r *SyncResult) reject(err error) {
+	r.Status = SyncStatusRejected
+	r.Error = err.Error()
+}
+
+// resolveConflict reports whether a change loses to the server's copy of its record,
+// recording the outcome if so. A record the server has deleted stays deleted, and one the
+// server updated after the client's change keeps the server's version.
+func resolveConflict(change SyncChange, record interface{}, updatedAt time.Time, deleted bool, result *SyncResult) bool {
+	if deleted {
+		if change.Op == SyncOpDelete {
+			result.Status = SyncStatusDeleted
+		} else {
+			result.Status = SyncStatusConflict
+			result.Error = "the record has been deleted"
+		}
+		return true
+	}
+	if updatedAt.After(change.UpdatedAt) {
+		result.Status = SyncStatusConflict
+		result.Error = "the record was changed more recently"
+		result.Record = record
+		return true
+	}
+	return false
+}
+
+// decodeSyncData decodes the data of an upsert, rejecting the change if it is missing or malformed
+func decodeSyncData(change SyncChange, data interface{}, result *SyncResult) bool {
+	if len(change.Data) == 0 || string(change.Data) == "null" {
+		result.reject(errors.New("data is required for an upsert"))
+		return false
+	}
+	if err := json.Unmarshal(change.Data, data); err != nil {
+		result.reject(fmt.Errorf("invalid data: %v", err))
+		return false
+	}
+	return true
+}
+
+// findSyncRecord looks up a user's record by UUID, including deleted records. found is
+// false if the server has never had it.
+func findSyncRecord(db *gorm.DB, userID uint, id string, record interface{}) (found bool, err error) {
+	err = db.Unscoped().Where("user_id = ? AND uuid = ?", userID, id).First(record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func pushExercise(db *gorm.DB, userID uint, change SyncChange, result *SyncResult) error {
+	var exercise models.Exercise
+	found, err := findSyncRecord(db, userID, change.UUID, &exercise)
+	if err != nil {
+		return err
+	}
+	if found && resolveConflict(change, exercise, exercise.UpdatedAt, exercise.DeletedAt.Valid, result) {
+		return nil
+	}
+
+	if change.Op == SyncOpDelete {
+		if found {
+			if err := db.Delete(&exercise).Error; err != nil {
+				return err
+			}
+		}
+		result.Status = SyncStatusDeleted
+		return nil
+	}
+
+	var data SyncExerciseData
+	if !decodeSyncData(change, &data, result) {
+		return nil
+	}
+
+	if !found {
+		exercise, err := createSyncExercise(db, userID, change.UUID, data)
+		if err != nil {
+			return rejectOr(result, err)
+		}
+		result.Status = SyncStatusCreated
+		result.Record = exercise
+		return nil
+	}
+
+	if err := updateSyncExercise(db, &exercise, data); err != nil {
+		return rejectOr(result, err)
+	}
+	result.Status = SyncStatusUpdated
+	result.Record = exercise
+	return nil
+}
+
+// syncRejection is a change that can't be applied, as opposed to a failure applying it
+type syncRejection struct {
+	err error
+}
+
+func (r syncRejection) Error() string {
+	return r.err.Error()
+}
+
+func rejected(err error) error {
+	return syncRejection{err: err}
+}
+
+// rejectOr rejects the change for a syncRejection and returns any other error
+func rejectOr(result *SyncResult, err error) error {
+	var rejection syncRejection
+	if errors.As(err, &rejection) {
+		result.reject(rejection.err)
+		return nil
+	}
+	return err
+}
+
+func createSyncExercise(db *gorm.DB, userID uint, id string, data SyncExerciseData) (*models.Exercise, error) {
+	var entry *models.CatalogExercise
+	if data.CatalogID != nil {
+		var err error
+		entry, err = GetCatalogExercise(*data.CatalogID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rejected(errors.New("catalog exercise not found"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(data.Ticker) == "" {
+			data.Ticker = entry.Ticker
+		}
+		if strings.TrimSpace(data.Name) == "" {
+			data.Name = entry.Name
+		}
+		if data.Description == "" {
+			data.Description = entry.Description
+		}
+		if strings.TrimSpace(data.Category) == "" {
+			data.Category = entry.Category
+		}
+		if data.MeasurementType == "" {
+			data.MeasurementType = entry.MeasurementType
+		}
+	}
+
+	exercise := models.Exercise{
+		UserID:            userID,
+		UUID:              id,
+		Ticker:            strings.ToUpper(strings.TrimSpace(data.Ticker)),
+		Name:              strings.TrimSpace(data.Name),
+		Description:       data.Description,
+		Category:          strings.TrimSpace(data.Category),
+		StockPrice:        InitialStockPrice,
+		CatalogExerciseID: data.CatalogID,
+		MeasurementType:   data.MeasurementType,
+	}
+	if exercise.Name == "" || exercise.Category == "" {
+		return nil, rejected(errors.New("name and category are required unless catalog_id is given"))
+	}
+	if data.ScoringStrategy != nil && *data.ScoringStrategy != "" {
+		if _, err := scoring.Get(*data.ScoringStrategy); err != nil {
+			return nil, rejected(errors.New("unknown scoring strategy"))
+		}
+		exercise.ScoringStrategy = *data.ScoringStrategy
+	}
+	if exercise.MeasurementType == "" {
+		exercise.MeasurementType = MeasurementWeightReps
+	}
+	if err := ValidateMeasurementType(exercise.MeasurementType); err != nil {
+		return nil, rejected(err)
+	}
+	if err := checkSyncTicker(db, exercise); err != nil {
+		return nil, err
+	}
+
+	if err := db.Omit("User", "WorkoutEntries", "CatalogExercise").Create(&exercise).Error; err != nil {
+		return nil, err
+	}
+	exercise.CatalogExercise = entry
+	return &exercise, nil
+}
+
+func updateSyncExercise(db *gorm.DB, exercise *models.Exercise, data SyncExerciseData) error {
+	if ticker := strings.ToUpper(strings.TrimSpace(data.Ticker)); ticker != "" && ticker != exercise.Ticker {
+		exercise.Ticker = ticker
+		if err := checkSyncTicker(db, *exercise); err != nil {
+			return err
+		}
+	}
+	if name := strings.TrimSpace(data.Name); name != "" {
+		exercise.Name = name
+	}
+	if data.Description != "" {
+		exercise.Description = data.Description
+	}
+	if category := strings.TrimSpace(data.Category); category != "" {
+		exercise.Category = category
+	}
+	if data.CatalogID != nil {
+		exercise.CatalogExerciseID = nil
+		if *data.CatalogID != 0 {
+			entry, err := GetCatalogExercise(*data.CatalogID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return rejected(errors.New("catalog exercise not found"))
+				}
+				return err
+			}
+			exercise.CatalogExerciseID = &entry.ID
+		}
+	}
+
+	rescore := false
+	if data.ScoringStrategy != nil && *data.ScoringStrategy != exercise.ScoringStrategy {
+		if *data.ScoringStrategy != "" {
+			if _, err := scoring.Get(*data.ScoringStrategy); err != nil {
+				return rejected(errors.New("unknown scoring strategy"))
+			}
+		}
+		exercise.ScoringStrategy = *data.ScoringStrategy
+		rescore = true
+	}
+
+	if data.MeasurementType != "" && data.MeasurementType != MeasurementType(*exercise) {
+		if err := ValidateMeasurementType(data.MeasurementType); err != nil {
+			return rejected(err)
+		}
+		// Existing entries were recorded in the old type's fields
+		var entryCount int64
+		if err := db.Model(&models.WorkoutEntry{}).Where("exercise_id = ?", exercise.ID).Count(&entryCount).Error; err != nil {
+			return err
+		}
+		if entryCount > 0 {
+			return rejected(errors.New("measurement type cannot be changed once an exercise has entries"))
+		}
+		exercise.MeasurementType = data.MeasurementType
+	}
+
+	if err := db.Omit("User", "WorkoutEntries", "CatalogExercise").Save(exercise).Error; err != nil {
+		return err
+	}
+
+	// Existing entries were scored with the old strategy; the next pull returns them rescored
+	if rescore {
+		if _, err := StartRescoreJob(exercise.UserID, RescoreReasonStrategy, []uint{exercise.ID}); err != nil {
+			return err
+		}
+	}
+	return db.Preload("CatalogExercise").First(exercise, exercise.ID).Error
+}
+
+// checkSyncTicker checks an exercise's ticker is valid and not used by another of the
+// user's exercises
+func checkSyncTicker(db *gorm.DB, exercise models.Exercise) error {
+	if err := ValidateTicker(exercise.Ticker); err != nil {
+		return rejected(err)
+	}
+	var count int64
+	err := db.Model(&models.Exercise{}).
+		Where("user_id = ? AND ticker = ? AND id != ?", exercise.UserID, exercise.Ticker, exercise.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return rejected(errors.New("ticker symbol already exists for this user"))
+	}
+	return nil
+}
+
+func pushEntry(db *gorm.DB, userID uint, change SyncChange, result *SyncResult) error {
+	var entry models.WorkoutEntry
+	found, err := findSyncRecord(preloadSets(db), userID, change.UUID, &entry)
+	if err != nil {
+		return err
+	}
+	if found && resolveConflict(change, entry, entry.UpdatedAt, entry.DeletedAt.Valid, result) {
+		return nil
+	}
+
+	if change.Op == SyncOpDelete {
+		if found {
+			if err := DeleteEntry(userID, entry.ID); err != nil {
+				return err
+			}
+		}
+		result.Status = SyncStatusDeleted
+		return nil
+	}
+
+	var data SyncEntryData
+	if !decodeSyncData(change, &data, result) {
+		return nil
+	}
+
+	var saved *models.WorkoutEntry
+	if !found {
+		saved, err = createSyncEntry(db, userID, change, data)
+		result.Status = SyncStatusCreated
+	} else {
+		saved, err = updateSyncEntry(db, &entry, data)
+		result.Status = SyncStatusUpdated
+	}
+	if err != nil {
+		return rejectOr(result, err)
+	}
+	result.Record = saved
+	return nil
+}
+
+// syncEntryExercise returns the live exercise an entry upsert names
+func syncEntryExercise(db *gorm.DB, userID uint, data SyncEntryData) (*models.Exercise, error) {
+	query := db.Where("user_id = ?", userID)
+	switch {
+	case data.ExerciseUUID != "":
+		query = query.Where("uuid = ?", data.ExerciseUUID)
+	case data.ExerciseID != 0:
+		query = query.Where("id = ?", data.ExerciseID)
+	default:
+		return nil, rejected(errors.New("exercise_uuid or exercise_id is required"))
+	}
+
+	var exercise models.Exercise
+	if err := query.First(&exercise).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rejected(errors.New("exercise not found"))
+		}
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+// syncEntrySession checks the session an entry upsert names belongs to the user
+func syncEntrySession(db *gorm.DB, userID uint, sessionID *uint) (*models.WorkoutSession, error) {
+	if sessionID == nil {
+		return nil, nil
+	}
+	var session models.WorkoutSession
+	if err := db.Where("id = ? AND user_id = ?", *sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rejected(errors.New("session not found"))
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// syncEntrySets validates the sets of an entry upsert, keeping only what the client records
+func syncEntrySets(measurementType string, data SyncEntryData) ([]models.WorkoutSet, error) {
+	sets := make([]models.WorkoutSet, 0, len(data.WorkoutSets))
+	for _, set := range data.WorkoutSets {
+		sets = append(sets, models.WorkoutSet{
+			Type:     set.Type,
+			Weight:   set.Weight,
+			Reps:     set.Reps,
+			Duration: set.Duration,
+			Distance: set.Distance,
+			RPE:      set.RPE,
+			RIR:      set.RIR,
+		})
+	}
+	if err := ValidateSets(measurementType, sets); err != nil {
+		return nil, rejected(err)
+	}
+	if err := ValidateEffort(data.RPE, data.RIR); err != nil {
+		return nil, rejected(err)
+	}
+	return sets, nil
+}
+
+func createSyncEntry(db *gorm.DB, userID uint, change SyncChange, data SyncEntryData) (*models.WorkoutEntry, error) {
+	exercise, err := syncEntryExercise(db, userID, data)
+	if err != nil {
+		return nil, err
+	}
+	session, err := syncEntrySession(db, userID, data.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries logged in a session default to the session's start, others to when they were logged
+	date := change.UpdatedAt
+	if session != nil {
+		date = session.StartedAt
+	}
+	if data.Date != "" {
+		date, err = time.Parse("2006-01-02", data.Date)
+		if err != nil {
+			return nil, rejected(errors.New("invalid date format, use YYYY-MM-DD"))
+		}
+	}
+
+	measurementType := MeasurementType(*exercise)
+	sets, err := syncEntrySets(measurementType, data)
+	if err != nil {
+		return nil, err
+	}
+	summary := SummarizeSets(measurementType, sets)
+	score, err := ScoreEntry(*exercise, sets, date, data.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.WorkoutEntry{
+		UserID:          userID,
+		UUID:            change.UUID,
+		ExerciseID:      exercise.ID,
+		SessionID:       data.SessionID,
+		Weight:          summary.Weight,
+		Reps:            summary.Reps,
+		Sets:            summary.Sets,
+		Duration:        summary.Duration,
+		Distance:        summary.Distance,
+		RPE:             data.RPE,
+		RIR:             data.RIR,
+		Notes:           data.Notes,
+		Date:            date,
+		Score:           score.Value,
+		ScoringStrategy: score.Strategy,
+		ScoringVersion:  score.Version,
+		WorkoutSets:     sets,
+	}
+
+	// Offline entries arrive in any order, so the exercise's history is replayed rather
+	// than compared against its current bests
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Exercise").Create(&entry).Error; err != nil {
+			return err
+		}
+		return rebuildPRHistory(tx, exercise.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := RepriceExercise(exercise.ID, PriceReasonEntryCreated); err != nil {
+		return nil, err
+	}
+
+	return GetEntry(userID, entry.ID)
+}
+
+func updateSyncEntry(db *gorm.DB, entry *models.WorkoutEntry, data SyncEntryData) (*models.WorkoutEntry, error) {
+	if data.ExerciseUUID != "" || data.ExerciseID != 0 {
+		exercise, err := syncEntryExercise(db, entry.UserID, data)
+		if err != nil {
+			return nil, err
+		}
+		if exercise.ID != entry.ExerciseID {
+			return nil, rejected(errors.New("an entry cannot be moved to another exercise"))
+		}
+	}
+
+	if data.SessionID != nil {
+		if _, err := syncEntrySession(db, entry.UserID, data.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	entry.SessionID = data.SessionID
+	if data.Date != "" {
+		date, err := time.Parse("2006-01-02", data.Date)
+		if err != nil {
+			return nil, rejected(errors.New("invalid date format, use YYYY-MM-DD"))
+		}
+		entry.Date = date
+	}
+	entry.Notes = data.Notes
+	entry.RPE = data.RPE
+	entry.RIR = data.RIR
+
+	var sets []models.WorkoutSet
+	if data.WorkoutSets != nil {
+		var exercise models.Exercise
+		if err := db.First(&exercise, entry.ExerciseID).Error; err != nil {
+			return nil, err
+		}
+		var err error
+		sets, err = syncEntrySets(MeasurementType(exercise), data)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := ValidateEffort(data.RPE, data.RIR); err != nil {
+		return nil, rejected(err)
+	}
+
+	if err := UpdateEntry(entry, sets); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func pushBodyweight(db *gorm.DB, userID uint, change SyncChange, result *SyncResult) error {
+	var entry models.BodyweightEntry
+	found, err := findSyncRecord(db, userID, change.UUID, &entry)
+	if err != nil {
+		return err
+	}
+	if found && resolveConflict(change, entry, entry.UpdatedAt, entry.DeletedAt.Valid, result) {
+		return nil
+	}
+
+	if change.Op == SyncOpDelete {
+		if found {
+			if err := db.Delete(&entry).Error; err != nil {
+				return err
+			}
+			if _, err := StartBodyweightRescore(userID, entry.ID, entry.RecordedAt); err != nil {
+				return err
+			}
+		}
+		result.Status = SyncStatusDeleted
+		return nil
+	}
+
+	var data SyncBodyweightData
+	if !decodeSyncData(change, &data, result) {
+		return nil
+	}
+	if data.Weight <= 0 {
+		result.reject(errors.New("weight must be greater than 0"))
+		return nil
+	}
+
+	// The times around which entries were scored against this bodyweight
+	var rescoreAt []time.Time
+	if !found {
+		entry = models.BodyweightEntry{
+			UserID:     userID,
+			UUID:       change.UUID,
+			Weight:     data.Weight,
+			Unit:       data.Unit,
+			RecordedAt: data.RecordedAt,
+		}
+		if entry.Unit == "" {
+			entry.Unit = "kg"
+		}
+		// Bodyweight logged offline was recorded when it was logged
+		if entry.RecordedAt.IsZero() {
+			entry.RecordedAt = change.UpdatedAt
+		}
+		if err := db.Create(&entry).Error; err != nil {
+			return err
+		}
+		result.Status = SyncStatusCreated
+	} else {
+		recordedAt := entry.RecordedAt
+		entry.Weight = data.Weight
+		if data.Unit != "" {
+			entry.Unit = data.Unit
+		}
+		if !data.RecordedAt.IsZero() {
+			entry.RecordedAt = data.RecordedAt
+		}
+		if err := db.Save(&entry).Error; err != nil {
+			return err
+		}
+		// Entries around both the old and the new date were scored against this entry
+		if !recordedAt.Equal(entry.RecordedAt) {
+			rescoreAt = append(rescoreAt, recordedAt)
+		}
+		result.Status = SyncStatusUpdated
+	}
+
+	rescoreAt = append(rescoreAt, entry.RecordedAt)
+	if _, err := StartBodyweightRescore(userID, entry.ID, rescoreAt...); err != nil {
+		return err
+	}
+	result.Record = entry
+	return nil
+}
+
+func encodeSyncToken(t time.Time) string {
+	data, _ := json.Marshal(syncToken{Time: t.UnixNano()})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSyncToken(value string) (time.Time, error) {
+	var token syncToken
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return time.Time{}, ErrInvalidSyncToken
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.Time <= 0 {
+		return time.Time{}, ErrInvalidSyncToken
+	}
+	return time.Unix(0, token.Time), nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"fitness-market/internal/models"
+	"fitness-market/internal/scoring"
+
+	"gorm.io/gorm"
+)
+
+// ageRecords moves every timestamp of the exercise's records an hour back, as if they were
+// pulled long ago
+func ageRecords(t *testing.T, db *gorm.DB, exerciseID uint) {
+	t.Helper()
+	past := time.Now().Add(-time.Hour)
+	for _, model := range []interface{}{&models.WorkoutEntry{}, &models.PRHistory{}, &models.PriceHistory{}} {
+		err := db.Model(model).Where("exercise_id = ?", exerciseID).
+			UpdateColumns(map[string]interface{}{"created_at": past, "updated_at": past}).Error
+		if err != nil {
+			t.Fatalf("age records: %v", err)
+		}
+	}
+	if err := db.Model(&models.Exercise{}).Where("id = ?", exerciseID).UpdateColumn("updated_at", past).Error; err != nil {
+		t.Fatalf("age exercise: %v", err)
+	}
+}
+
+// pulledEntries returns the entries of a pull by ID
+func pulledEntries(t *testing.T, userID uint, token string) map[uint]models.WorkoutEntry {
+	t.Helper()
+	pull, err := PullChanges(userID, token)
+	if err != nil {
+		t.Fatalf("PullChanges: %v", err)
+	}
+	entries := map[uint]models.WorkoutEntry{}
+	for _, entry := range pull.Entries.Updated {
+		entries[entry.ID] = entry
+	}
+	return entries
+}
+
+func TestPullAfterRescore(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "BP")
+
+	day := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+	first := createTestEntry(t, db, exercise, day, 80, 5)
+	second := createTestEntry(t, db, exercise, day.AddDate(0, 0, 7), 90, 5)
+	if err := RebuildPRHistory(exercise.ID); err != nil {
+		t.Fatalf("RebuildPRHistory: %v", err)
+	}
+	ageRecords(t, db, exercise.ID)
+	token := encodeSyncToken(time.Now())
+
+	if entries := pulledEntries(t, user.ID, token); len(entries) != 0 {
+		t.Fatalf("pulled %d entries before any change, want 0", len(entries))
+	}
+
+	// A strategy change rescores the entries without a client having touched them
+	if err := db.Model(&exercise).UpdateColumn("scoring_strategy", "e1rm_epley").Error; err != nil {
+		t.Fatalf("set strategy: %v", err)
+	}
+	if err := RescoreExercise(exercise.ID); err != nil {
+		t.Fatalf("RescoreExercise: %v", err)
+	}
+	entries := pulledEntries(t, user.ID, token)
+	for _, id := range []uint{first.ID, second.ID} {
+		entry, ok := entries[id]
+		if !ok {
+			t.Fatalf("entry %d was rescored but not pulled", id)
+		}
+		if entry.ScoringStrategy != "e1rm_epley" || entry.Score != scoring.EpleyOneRepMax(entry.Weight, entry.Reps) {
+			t.Errorf("entry %d pulled with %s score %.2f, want e1rm_epley %.2f",
+				id, entry.ScoringStrategy, entry.Score, scoring.EpleyOneRepMax(entry.Weight, entry.Reps))
+		}
+	}
+
+	// A backdated record takes the PR flag from the entry that used to hold it
+	ageRecords(t, db, exercise.ID)
+	token = encodeSyncToken(time.Now())
+	backdated := createTestEntry(t, db, exercise, day.AddDate(0, 0, 3), 100, 5)
+	if _, _, err := ReplayPRHistory(backdated); err != nil {
+		t.Fatalf("ReplayPRHistory: %v", err)
+	}
+	entries = pulledEntries(t, user.ID, token)
+	if entry, ok := entries[second.ID]; !ok || entry.IsPR {
+		t.Errorf("entry %d pulled = %v with is_pr %v, want pulled with is_pr false", second.ID, ok, entry.IsPR)
+	}
+	if entry, ok := entries[backdated.ID]; !ok || !entry.IsPR {
+		t.Errorf("backdated entry pulled = %v with is_pr %v, want pulled with is_pr true", ok, entry.IsPR)
+	}
+}
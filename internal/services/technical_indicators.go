@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/indicators"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Default indicator settings for the types that take no period
+const (
+	MACDFastPeriod   = 12
+	MACDSlowPeriod   = 26
+	MACDSignalPeriod = 9
+	BollingerPeriod  = 20
+	BollingerWidth   = 2.0
+)
+
+// maxIndicatorPeriod keeps a requested lookback within a sensible range
+const maxIndicatorPeriod = 365
+
+// ErrInvalidIndicator is returned for an indicator type that cannot be parsed
+var ErrInvalidIndicator = errors.New("types must be a comma-separated list of smaN, emaN, rsiN, macd or bollinger")
+
+// IndicatorSeries is one line of an indicator, aligned with IndicatorResult.Dates.
+// Points still inside the indicator's warmup period are null.
+type IndicatorSeries []*float64
+
+// IndicatorResult is an exercise's daily close series with the requested indicators over it
+type IndicatorResult struct {
+	Dates      []time.Time                           `json:"dates"`
+	Close      []float64                             `json:"close"`
+	Indicators map[string]map[string]IndicatorSeries `json:"indicators"`
+}
+
+// indicatorFunc computes the named lines of one indicator over a close series
+type indicatorFunc func(closes []float64) map[string][]float64
+
+// ParseIndicatorTypes validates a comma-separated list such as "sma20,ema10,rsi14,macd,bollinger"
+// and returns the distinct, lower-cased types in order
+func ParseIndicatorTypes(types string) ([]string, error) {
+	var parsed []string
+	seen := map[string]bool{}
+	for _, raw := range strings.Split(types, ",") {
+		key := strings.ToLower(strings.TrimSpace(raw))
+		if key == "" || seen[key] {
+			continue
+		}
+		if _, err := indicatorFor(key); err != nil {
+			return nil, err
+		}
+		seen[key] = true
+		parsed = append(parsed, key)
+	}
+	if len(parsed) == 0 {
+		return nil, ErrInvalidIndicator
+	}
+	return parsed, nil
+}
+
+// GetExerciseIndicators computes indicators over an exercise's daily score closes, which
+// splits don't change. The whole entry history feeds each indicator so warmup periods are
+// filled from data before from, but only points between from and to are returned.
+func GetExerciseIndicators(exerciseID uint, types []string, from time.Time, to time.Time) (*IndicatorResult, error) {
+	result := &IndicatorResult{
+		Dates:      []time.Time{},
+		Close:      []float64{},
+		Indicators: map[string]map[string]IndicatorSeries{},
+	}
+
+	var first models.WorkoutEntry
+	err := database.GetDB().
+		Where("exercise_id = ?", exerciseID).
+		Order("date ASC, id ASC").
+		First(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		for _, key := range types {
+			result.Indicators[key] = map[string]IndicatorSeries{}
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	candles, err := GetCandles(exerciseID, CandleIntervalDay, first.Date, to)
+	if err != nil {
+		return nil, err
+	}
+
+	closes := make([]float64, len(candles))
+	for i, candle := range candles {
+		closes[i] = candle.Close
+	}
+
+	start := 0
+	fromDay := truncateToInterval(from, CandleIntervalDay)
+	for start < len(candles) && candles[start].Time.Before(fromDay) {
+		start++
+	}
+	for _, candle := range candles[start:] {
+		result.Dates = append(result.Dates, candle.Time)
+		result.Close = append(result.Close, candle.Close)
+	}
+
+	for _, key := range types {
+		compute, err := indicatorFor(key)
+		if err != nil {
+			return nil, err
+		}
+		lines := map[string]IndicatorSeries{}
+		for name, values := range compute(closes) {
+			lines[name] = toIndicatorSeries(values[start:])
+		}
+		result.Indicators[key] = lines
+	}
+
+	return result, nil
+}
+
+// indicatorFor maps an indicator type to the function that computes it
+func indicatorFor(key string) (indicatorFunc, error) {
+	switch key {
+	case "macd":
+		return func(closes []float64) map[string][]float64 {
+			macd, signal, histogram := indicators.MACD(closes, MACDFastPeriod, MACDSlowPeriod, MACDSignalPeriod)
+			return map[string][]float64{"macd": macd, "signal": signal, "histogram": histogram}
+		}, nil
+	case "bollinger":
+		return func(closes []float64) map[string][]float64 {
+			middle, upper, lower := indicators.Bollinger(closes, BollingerPeriod, BollingerWidth)
+			return map[string][]float64{"middle": middle, "upper": upper, "lower": lower}
+		}, nil
+	}
+
+	for prefix, compute := range map[string]func([]float64, int) []float64{
+		"sma": indicators.SMA,
+		"ema": indicators.EMA,
+		"rsi": indicators.RSI,
+	} {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		period, err := strconv.Atoi(strings.TrimPrefix(key, prefix))
+		if err != nil || period < 1 || period > maxIndicatorPeriod {
+			return nil, fmt.Errorf("%w: invalid period in %q", ErrInvalidIndicator, key)
+		}
+		compute := compute
+		return func(closes []float64) map[string][]float64 {
+			return map[string][]float64{"value": compute(closes, period)}
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidIndicator, key)
+}
+
+func toIndicatorSeries(values []float64) IndicatorSeries {
+	series := make(IndicatorSeries, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		rounded := math.Round(v*10000) / 10000
+		series[i] = &rounded
+	}
+	return series
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Trade sides
+const (
+	TradeSideBuy  = "buy"
+	TradeSideSell = "sell"
+)
+
+// StartingCashBalance is the virtual cash every user is given to invest with
+const StartingCashBalance = 10000.0
+
+// shareEpsilon absorbs floating point dust when comparing share counts
+const shareEpsilon = 1e-9
+
+var (
+	ErrInvalidTradeSide   = errors.New("side must be buy or sell")
+	ErrInvalidTradeShares = errors.New("shares must be greater than 0")
+	ErrInsufficientCash   = errors.New("insufficient cash balance")
+	ErrInsufficientShares = errors.New("insufficient shares")
+	ErrTickerDelisted     = errors.New("the exercise has been deleted; its shares can only be sold")
+	ErrTradingHalted      = errors.New("trading is halted on a manually set price until the exercise is next repriced")
+)
+
+// EnsureStartingCash deposits StartingCashBalance for a user who has never received it,
+// dated when the account was created so the user's history holds it from the start.
+// Concurrent first requests race to post the deposit; the unique index on deposits lets
+// only one of them through and the others find it already made.
+func EnsureStartingCash(userID uint) error {
+	db := database.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		made, err := hasStartingCash(tx, userID)
+		if err != nil || made {
+			return err
+		}
+		var user models.User
+		if err := tx.Unscoped().Select("id", "created_at").First(&user, userID).Error; err != nil {
+			return err
+		}
+		return PostLedgerEntry(tx, &models.CashLedgerEntry{
+			UserID:      userID,
+			Type:        LedgerTypeDeposit,
+			Amount:      StartingCashBalance,
+			Description: "Starting cash balance",
+			OccurredAt:  user.CreatedAt,
+		})
+	})
+	if err != nil {
+		if made, checkErr := hasStartingCash(db, userID); checkErr == nil && made {
+			return nil
+		}
+	}
+	return err
+}
+
+func hasStartingCash(db *gorm.DB, userID uint) (bool, error) {
+	var deposits int64
+	err := db.Unscoped().Model(&models.CashLedgerEntry{}).
+		Where("user_id = ? AND type = ?", userID, LedgerTypeDeposit).
+		Count(&deposits).Error
+	return deposits > 0, err
+}
+
+// priceOverridden reports whether an exercise's price was last set by hand. Splits only
+// rescale the price, so they leave an override in place.
+func priceOverridden(db *gorm.DB, exerciseID uint) (bool, error) {
+	var latest models.PriceHistory
+	err := db.Where("exercise_id = ? AND reason NOT IN ?", exerciseID, []string{CorporateActionSplit, CorporateActionReverseSplit}).
+		Order("recorded_at DESC, id DESC").
+		Limit(1).
+		Find(&latest).Error
+	return latest.Reason == PriceReasonManualOverride, err
+}
+
+// ExecuteTrade buys or sells shares of one of the user's exercises at its current stock price,
+// settling the cost against their cash balance and updating their holding. Shares of a
+// deleted exercise can still be sold, at its last price, but no longer bought. Trading is
+// halted while the price is a manual override, until a workout entry reprices it.
+func ExecuteTrade(userID uint, exerciseID uint, side string, shares float64) (*models.Trade, error) {
+	if side != TradeSideBuy && side != TradeSideSell {
+		return nil, ErrInvalidTradeSide
+	}
+	if shares <= 0 {
+		return nil, ErrInvalidTradeShares
+	}
+	if err := EnsureStartingCash(userID); err != nil {
+		return nil, err
+	}
+
+	var trade models.Trade
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var exercise models.Exercise
+		if err := tx.Unscoped().Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+			return err
+		}
+		if exercise.DeletedAt.Valid && side == TradeSideBuy {
+			return ErrTickerDelisted
+		}
+		halted, err := priceOverridden(tx, exercise.ID)
+		if err != nil {
+			return err
+		}
+		if halted {
+			return ErrTradingHalted
+		}
+
+		var holding models.Holding
+		err = tx.Where(models.Holding{UserID: userID, ExerciseID: exerciseID}).FirstOrCreate(&holding).Error
+		if err != nil {
+			return err
+		}
+
+		amount := roundPrice(shares * exercise.StockPrice)
+		ledgerEntry := models.CashLedgerEntry{
+			UserID:     userID,
+			ExerciseID: &exercise.ID,
+		}
+
+		switch side {
+		case TradeSideBuy:
+			balance, err := cashBalance(tx, userID)
+			if err != nil {
+				return err
+			}
+			if amount > balance {
+				return ErrInsufficientCash
+			}
+			holding.AverageCost = (holding.Shares*holding.AverageCost + amount) / (holding.Shares + shares)
+			holding.Shares += shares
+			ledgerEntry.Type = LedgerTypeTradeBuy
+			ledgerEntry.Amount = -amount
+			ledgerEntry.Description = fmt.Sprintf("Bought %g %s @ %.2f", shares, exercise.Ticker, exercise.StockPrice)
+		case TradeSideSell:
+			if shares > holding.Shares+shareEpsilon {
+				return ErrInsufficientShares
+			}
+			holding.Shares -= shares
+			if holding.Shares < shareEpsilon {
+				holding.Shares = 0
+				holding.AverageCost = 0
+			}
+			ledgerEntry.Type = LedgerTypeTradeSell
+			ledgerEntry.Amount = amount
+			ledgerEntry.Description = fmt.Sprintf("Sold %g %s @ %.2f", shares, exercise.Ticker, exercise.StockPrice)
+		}
+
+		if err := PostLedgerEntry(tx, &ledgerEntry); err != nil {
+			return err
+		}
+		if err := tx.Save(&holding).Error; err != nil {
+			return err
+		}
+
+		trade = models.Trade{
+			UserID:        userID,
+			ExerciseID:    exercise.ID,
+			Side:          side,
+			Shares:        shares,
+			Price:         exercise.StockPrice,
+			Amount:        amount,
+			LedgerEntryID: ledgerEntry.ID,
+			ExecutedAt:    ledgerEntry.OccurredAt,
+		}
+		return tx.Create(&trade).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &trade, nil
+}
+
+// GetHoldings returns the user's open positions with their exercises, including positions
+// in exercises deleted since
+func GetHoldings(userID uint) ([]models.Holding, error) {
+	var holdings []models.Holding
+	err := database.GetDB().
+		Where("user_id = ? AND shares > ?", userID, shareEpsilon).
+		Preload("Exercise", unscoped).
+		Order("exercise_id ASC").
+		Find(&holdings).Error
+
+	return holdings, err
+}
+
+// unscoped preloads records that have since been deleted
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// GetTrades returns the user's trade history, newest first, optionally for one exercise
+func GetTrades(userID uint, exerciseID uint) ([]models.Trade, error) {
+	query := database.GetDB().Where("user_id = ?", userID)
+	if exerciseID != 0 {
+		query = query.Where("exercise_id = ?", exerciseID)
+	}
+
+	var trades []models.Trade
+	err := query.Preload("Exercise", unscoped).
+		Order("executed_at DESC, id DESC").
+		Find(&trades).Error
+
+	return trades, err
+}
+
+// GetSharesAt replays the user's trades to find how many shares of each exercise they held
+// at the given time, accounting for splits between each trade and that time
+func GetSharesAt(userID uint, at time.Time) (map[uint]float64, error) {
+	at = at.UTC()
+
+	var trades []models.Trade
+	err := database.GetDB().
+		Where("user_id = ? AND executed_at <= ?", userID, at).
+		Order("executed_at ASC, id ASC").
+		Find(&trades).Error
+	if err != nil {
+		return nil, err
+	}
+
+	shares := map[uint]float64{}
+	for _, trade := range trades {
+		factor, err := GetSplitFactor(trade.ExerciseID, trade.ExecutedAt, at)
+		if err != nil {
+			return nil, err
+		}
+		delta := trade.Shares * factor
+		if trade.Side == TradeSideSell {
+			delta = -delta
+		}
+		shares[trade.ExerciseID] += delta
+	}
+
+	return shares, nil
+}
+
+// GetCashBalanceAt returns the user's cash balance as it stood at the given time
+func GetCashBalanceAt(userID uint, at time.Time) (float64, error) {
+	var balance float64
+	err := database.GetDB().Model(&models.CashLedgerEntry{}).
+		Where("user_id = ? AND occurred_at <= ?", userID, at.UTC()).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+
+	return roundPrice(balance), err
+}
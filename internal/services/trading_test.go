@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExecuteTrade(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	exercise := createTestExercise(t, db, user.ID, "DL")
+
+	buy, err := ExecuteTrade(user.ID, exercise.ID, TradeSideBuy, 10)
+	if err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if buy.Price != 100 || buy.Amount != 1000 {
+		t.Errorf("bought at %v for %v, want 100 for 1000", buy.Price, buy.Amount)
+	}
+	if balance, _ := cashBalance(db, user.ID); balance != StartingCashBalance-1000 {
+		t.Errorf("cash after buying = %v, want %v", balance, StartingCashBalance-1000)
+	}
+
+	if _, err := ExecuteTrade(user.ID, exercise.ID, TradeSideBuy, 1000); !errors.Is(err, ErrInsufficientCash) {
+		t.Errorf("buying beyond cash: err = %v, want %v", err, ErrInsufficientCash)
+	}
+	if _, err := ExecuteTrade(user.ID, exercise.ID, TradeSideSell, 11); !errors.Is(err, ErrInsufficientShares) {
+		t.Errorf("selling more than held: err = %v, want %v", err, ErrInsufficientShares)
+	}
+
+	// A manual price halts trading until the exercise is repriced from its entries
+	if _, err := OverrideStockPrice(&exercise, 150); err != nil {
+		t.Fatalf("OverrideStockPrice: %v", err)
+	}
+	if _, err := ExecuteTrade(user.ID, exercise.ID, TradeSideSell, 1); !errors.Is(err, ErrTradingHalted) {
+		t.Errorf("trading an overridden price: err = %v, want %v", err, ErrTradingHalted)
+	}
+	if _, err := RepriceExercise(exercise.ID, PriceReasonEntryCreated); err != nil {
+		t.Fatalf("RepriceExercise: %v", err)
+	}
+
+	// Shares of a deleted exercise can be sold but not bought
+	if err := db.Delete(&exercise).Error; err != nil {
+		t.Fatalf("delete exercise: %v", err)
+	}
+	if _, err := ExecuteTrade(user.ID, exercise.ID, TradeSideBuy, 1); !errors.Is(err, ErrTickerDelisted) {
+		t.Errorf("buying a deleted exercise: err = %v, want %v", err, ErrTickerDelisted)
+	}
+	if _, err := ExecuteTrade(user.ID, exercise.ID, TradeSideSell, 10); err != nil {
+		t.Fatalf("selling a deleted exercise: %v", err)
+	}
+
+	holdings, err := GetHoldings(user.ID)
+	if err != nil {
+		t.Fatalf("GetHoldings: %v", err)
+	}
+	if len(holdings) != 0 {
+		t.Errorf("holdings after selling out = %d, want 0", len(holdings))
+	}
+	if balance, _ := cashBalance(db, user.ID); balance != StartingCashBalance {
+		t.Errorf("cash after selling out = %v, want %v", balance, StartingCashBalance)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"time"
+	_ "time/tzdata"
+
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+)
+
+const (
+	// DefaultTimezone is used for users who haven't set one on their profile
+	DefaultTimezone = "UTC"
+	// BackfillDays caps how far back daily jobs catch up on missed days after downtime
+	BackfillDays = 365
+)
+
+// GetUserLocation returns the time zone from the user's profile, falling back to UTC
+func GetUserLocation(userID uint) *time.Location {
+	var profile models.UserProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil || profile.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(profile.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// CalendarDate returns the calendar date of t in loc, as midnight UTC.
+// Dates keyed this way compare equal regardless of the user's time zone.
+func CalendarDate(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// EndOfDay returns the instant a calendar date (as returned by CalendarDate) ends in loc.
+// The result is in UTC so it compares correctly against stored timestamps.
+func EndOfDay(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, loc).UTC()
+}
+
+// dueDays lists the user's completed calendar dates after last, or since the day they
+// signed up when last is nil. Daily jobs use it to catch up on days missed during downtime.
+func dueDays(userID uint, now time.Time, loc *time.Location, last *time.Time) ([]time.Time, error) {
+	today := CalendarDate(now, loc)
+
+	var start time.Time
+	if last != nil {
+		start = CalendarDate(*last, time.UTC).AddDate(0, 0, 1)
+	} else {
+		var user models.User
+		if err := database.DB.First(&user, userID).Error; err != nil {
+			return nil, err
+		}
+		start = CalendarDate(user.CreatedAt, loc)
+	}
+
+	if earliest := today.AddDate(0, 0, -BackfillDays); start.Before(earliest) {
+		start = earliest
+	}
+
+	var days []time.Time
+	for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days, nil
+}
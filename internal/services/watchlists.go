@@ -0,0 +1,92 @@
+package services
+
+import (
+	"fitness-market/internal/database"
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GetWatchlists returns the user's watchlists with their tickers
+func GetWatchlists(userID uint) ([]models.Watchlist, error) {
+	var watchlists []models.Watchlist
+	err := database.GetDB().
+		Where("user_id = ?", userID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Items.Exercise").
+		Order("id ASC").
+		Find(&watchlists).Error
+
+	return watchlists, err
+}
+
+// GetWatchlist returns one of the user's watchlists with its tickers
+func GetWatchlist(userID uint, watchlistID uint) (*models.Watchlist, error) {
+	var watchlist models.Watchlist
+	err := database.GetDB().
+		Where("id = ? AND user_id = ?", watchlistID, userID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Items.Exercise").
+		First(&watchlist).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &watchlist, nil
+}
+
+// DeleteWatchlist removes one of the user's watchlists along with its items
+func DeleteWatchlist(userID uint, watchlistID uint) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var watchlist models.Watchlist
+		if err := tx.Where("id = ? AND user_id = ?", watchlistID, userID).First(&watchlist).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("watchlist_id = ?", watchlist.ID).Delete(&models.WatchlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&watchlist).Error
+	})
+}
+
+// AddWatchlistItem puts one of the user's exercises on a watchlist. Adding a ticker that is
+// already on the list is a no-op.
+func AddWatchlistItem(userID uint, watchlistID uint, exerciseID uint) (*models.WatchlistItem, error) {
+	db := database.GetDB()
+
+	var watchlist models.Watchlist
+	if err := db.Where("id = ? AND user_id = ?", watchlistID, userID).First(&watchlist).Error; err != nil {
+		return nil, err
+	}
+	var exercise models.Exercise
+	if err := db.Where("id = ? AND user_id = ?", exerciseID, userID).First(&exercise).Error; err != nil {
+		return nil, err
+	}
+
+	item := models.WatchlistItem{WatchlistID: watchlist.ID, ExerciseID: exercise.ID}
+	if err := db.Where(item).FirstOrCreate(&item).Error; err != nil {
+		return nil, err
+	}
+	item.Exercise = exercise
+
+	return &item, nil
+}
+
+// RemoveWatchlistItem takes an exercise off one of the user's watchlists
+func RemoveWatchlistItem(userID uint, watchlistID uint, exerciseID uint) error {
+	db := database.GetDB()
+
+	var watchlist models.Watchlist
+	if err := db.Where("id = ? AND user_id = ?", watchlistID, userID).First(&watchlist).Error; err != nil {
+		return err
+	}
+
+	result := db.Where("watchlist_id = ? AND exercise_id = ?", watchlist.ID, exerciseID).Delete(&models.WatchlistItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
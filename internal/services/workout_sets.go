@@ -0,0 +1,211 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"fitness-market/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Set types
+const (
+	SetTypeWarmup  = "warmup"
+	SetTypeWorking = "working"
+	SetTypeDrop    = "drop"
+	SetTypeFailure = "failure"
+)
+
+var (
+	ErrNoSets         = errors.New("workout_sets must contain at least one set")
+	ErrInvalidSetType = errors.New("set type must be one of warmup, working, drop or failure")
+	ErrInvalidRPE     = errors.New("rpe must be between 1 and 10")
+	ErrInvalidRIR     = errors.New("rir must be between 0 and 10")
+	ErrRPEAndRIR      = errors.New("give either rpe or rir, not both")
+	ErrNoWorkingSets  = errors.New("at least one set must be a working, drop or failure set")
+	ErrNonUniformSets = errors.New("this entry's sets differ from one another; change them through workout_sets")
+)
+
+// ExpandSets turns an N-sets performance into N identical working sets
+func ExpandSets(p Performance) []models.WorkoutSet {
+	sets := make([]models.WorkoutSet, 0, max(p.Sets, 1))
+	for i := 0; i < max(p.Sets, 1); i++ {
+		sets = append(sets, models.WorkoutSet{
+			SetIndex: i + 1,
+			Type:     SetTypeWorking,
+			Weight:   p.Weight,
+			Reps:     p.Reps,
+			Duration: p.Duration,
+			Distance: p.Distance,
+		})
+	}
+	return sets
+}
+
+// UniformSets reports whether an entry is just the N identical working sets its summary
+// describes, with no effort of their own, so it can be edited as weight x reps x sets
+func UniformSets(entry models.WorkoutEntry) bool {
+	sets := EntrySets(entry)
+	expanded := ExpandSets(EntryPerformance(entry))
+	if len(sets) != len(expanded) {
+		return false
+	}
+	for i, set := range sets {
+		want := expanded[i]
+		if set.Type != want.Type || set.Weight != want.Weight || set.Reps != want.Reps ||
+			set.Duration != want.Duration || set.Distance != want.Distance || set.RPE != nil || set.RIR != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// EntrySets returns an entry's sets, expanding its summary for an entry saved without them
+func EntrySets(entry models.WorkoutEntry) []models.WorkoutSet {
+	if len(entry.WorkoutSets) > 0 {
+		return entry.WorkoutSets
+	}
+	return ExpandSets(EntryPerformance(entry))
+}
+
+// WorkingSets returns the sets that count towards scores and records, leaving out warmups
+func WorkingSets(sets []models.WorkoutSet) []models.WorkoutSet {
+	working := make([]models.WorkoutSet, 0, len(sets))
+	for _, set := range sets {
+		if set.Type != SetTypeWarmup {
+			working = append(working, set)
+		}
+	}
+	return working
+}
+
+// ValidateSets checks every set records what the measurement type needs. Sets without a
+// type become working sets and set indexes are assigned in order.
+func ValidateSets(measurementType string, sets []models.WorkoutSet) error {
+	if len(sets) == 0 {
+		return ErrNoSets
+	}
+
+	hasWorking := false
+	for i := range sets {
+		set := &sets[i]
+		set.SetIndex = i + 1
+		if err := ValidateSet(measurementType, set); err != nil {
+			return fmt.Errorf("set %d: %w", set.SetIndex, err)
+		}
+		if set.Type != SetTypeWarmup {
+			hasWorking = true
+		}
+	}
+
+	if !hasWorking {
+		return ErrNoWorkingSets
+	}
+	return nil
+}
+
+// ValidateSet checks a single set records what the measurement type needs, making it a
+// working set if it has no type
+func ValidateSet(measurementType string, set *models.WorkoutSet) error {
+	switch set.Type {
+	case "":
+		set.Type = SetTypeWorking
+	case SetTypeWarmup, SetTypeWorking, SetTypeDrop, SetTypeFailure:
+	default:
+		return ErrInvalidSetType
+	}
+
+	p := Performance{Weight: set.Weight, Reps: set.Reps, Sets: 1, Duration: set.Duration, Distance: set.Distance}
+	if err := ValidatePerformance(measurementType, p); err != nil {
+		return err
+	}
+	return ValidateEffort(set.RPE, set.RIR)
+}
+
+// ValidateEffort checks an optional RPE or reps-in-reserve rating
+func ValidateEffort(rpe *float64, rir *int) error {
+	if rpe != nil && rir != nil {
+		return ErrRPEAndRIR
+	}
+	if rpe != nil && (*rpe < 1 || *rpe > 10) {
+		return ErrInvalidRPE
+	}
+	if rir != nil && (*rir < 0 || *rir > 10) {
+		return ErrInvalidRIR
+	}
+	return nil
+}
+
+// SetRPE returns the RPE of a set: its own RPE or RIR, else the entry's, converting reps in
+// reserve as RPE 10 - RIR. ok is false when no effort was recorded.
+func SetRPE(set models.WorkoutSet, entry models.WorkoutEntry) (rpe float64, ok bool) {
+	switch {
+	case set.RPE != nil:
+		return *set.RPE, true
+	case set.RIR != nil:
+		return 10 - float64(*set.RIR), true
+	case entry.RPE != nil:
+		return *entry.RPE, true
+	case entry.RIR != nil:
+		return 10 - float64(*entry.RIR), true
+	}
+	return 0, false
+}
+
+// SummarizeSets returns the top working set with Sets set to the number of working sets.
+// The top set is the heaviest for loaded exercises, the least assisted for assisted ones,
+// the most reps for reps-only, the longest for timed and the farthest for distance ones.
+func SummarizeSets(measurementType string, sets []models.WorkoutSet) Performance {
+	working := WorkingSets(sets)
+	if len(working) == 0 {
+		return Performance{}
+	}
+
+	top := working[0]
+	for _, set := range working[1:] {
+		if betterSet(measurementType, set, top) {
+			top = set
+		}
+	}
+
+	return Performance{
+		Weight:   top.Weight,
+		Reps:     top.Reps,
+		Sets:     len(working),
+		Duration: top.Duration,
+		Distance: top.Distance,
+	}
+}
+
+// betterSet reports whether a is a better top set than b
+func betterSet(measurementType string, a, b models.WorkoutSet) bool {
+	switch measurementType {
+	case MeasurementReps:
+		return a.Reps > b.Reps
+	case MeasurementTime:
+		return a.Duration > b.Duration
+	case MeasurementDistanceTime:
+		if a.Distance != b.Distance {
+			return a.Distance > b.Distance
+		}
+		return a.Duration < b.Duration
+	case MeasurementAssisted:
+		if a.Weight != b.Weight {
+			return a.Weight < b.Weight
+		}
+		return a.Reps > b.Reps
+	default:
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		return a.Reps > b.Reps
+	}
+}
+
+// preloadSets loads entries' sets in order
+func preloadSets(db *gorm.DB) *gorm.DB {
+	return db.Preload("WorkoutSets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("set_index ASC")
+	})
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+
+	"fitness-market/internal/models"
+)
+
+func TestUniformSets(t *testing.T) {
+	rpe := 8.0
+	tests := []struct {
+		name string
+		sets []models.WorkoutSet
+		want bool
+	}{
+		{
+			name: "saved without sets",
+			want: true,
+		},
+		{
+			name: "identical working sets",
+			sets: ExpandSets(Performance{Weight: 100, Reps: 5, Sets: 3}),
+			want: true,
+		},
+		{
+			name: "warmup before the working sets",
+			sets: append([]models.WorkoutSet{{Type: SetTypeWarmup, Weight: 60, Reps: 5}},
+				ExpandSets(Performance{Weight: 100, Reps: 5, Sets: 3})...),
+			want: false,
+		},
+		{
+			name: "reps drop off",
+			sets: []models.WorkoutSet{
+				{Type: SetTypeWorking, Weight: 100, Reps: 5},
+				{Type: SetTypeWorking, Weight: 100, Reps: 5},
+				{Type: SetTypeWorking, Weight: 100, Reps: 3},
+			},
+			want: false,
+		},
+		{
+			name: "drop set",
+			sets: []models.WorkoutSet{
+				{Type: SetTypeWorking, Weight: 100, Reps: 5},
+				{Type: SetTypeDrop, Weight: 100, Reps: 5},
+			},
+			want: false,
+		},
+		{
+			name: "per-set effort",
+			sets: []models.WorkoutSet{
+				{Type: SetTypeWorking, Weight: 100, Reps: 5},
+				{Type: SetTypeWorking, Weight: 100, Reps: 5, RPE: &rpe},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := models.WorkoutEntry{Weight: 100, Reps: 5, Sets: 3, WorkoutSets: tt.sets}
+			if len(tt.sets) > 0 {
+				summary := SummarizeSets(MeasurementWeightReps, tt.sets)
+				entry.Weight, entry.Reps, entry.Sets = summary.Weight, summary.Reps, summary.Sets
+			}
+			if got := UniformSets(entry); got != tt.want {
+				t.Errorf("UniformSets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
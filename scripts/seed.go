@@ -51,39 +51,44 @@ func Seed() {
 	// Create seed exercises
 	exercises := []models.Exercise{
 		{
-			UserID:      1,
-			Name:        "Push-ups",
-			Description: "Classic bodyweight exercise for chest and triceps",
-			Category:    "Strength",
-			StockPrice:  10.50,
+			UserID:          1,
+			Name:            "Push-ups",
+			Description:     "Classic bodyweight exercise for chest and triceps",
+			Category:        "Strength",
+			StockPrice:      10.50,
+			MeasurementType: "reps",
 		},
 		{
-			UserID:      1,
-			Name:        "Squats",
-			Description: "Lower body strength exercise",
-			Category:    "Strength",
-			StockPrice:  15.75,
+			UserID:          1,
+			Name:            "Squats",
+			Description:     "Lower body strength exercise",
+			Category:        "Strength",
+			StockPrice:      15.75,
+			MeasurementType: "reps",
 		},
 		{
-			UserID:      2,
-			Name:        "Running",
-			Description: "Cardiovascular endurance exercise",
-			Category:    "Cardio",
-			StockPrice:  8.25,
+			UserID:          2,
+			Name:            "Running",
+			Description:     "Cardiovascular endurance exercise",
+			Category:        "Cardio",
+			StockPrice:      8.25,
+			MeasurementType: "distance_time",
 		},
 		{
-			UserID:      2,
-			Name:        "Plank",
-			Description: "Core strengthening exercise",
-			Category:    "Core",
-			StockPrice:  12.00,
+			UserID:          2,
+			Name:            "Plank",
+			Description:     "Core strengthening exercise",
+			Category:        "Core",
+			StockPrice:      12.00,
+			MeasurementType: "time",
 		},
 		{
-			UserID:      3,
-			Name:        "Deadlifts",
-			Description: "Full body compound movement",
-			Category:    "Strength",
-			StockPrice:  25.50,
+			UserID:          3,
+			Name:            "Deadlifts",
+			Description:     "Full body compound movement",
+			Category:        "Strength",
+			StockPrice:      25.50,
+			MeasurementType: "weight_reps",
 		},
 	}
 
@@ -124,8 +129,8 @@ func Seed() {
 			ExerciseID: 3,
 			Date:       now.AddDate(0, 0, -1),
 			Sets:       1,
-			Reps:       1,
 			Duration:   1800, // 30 minutes
+			Distance:   5000,
 			Notes:      "5K run in the park",
 		},
 		{
@@ -190,4 +195,4 @@ func Seed() {
 
 	// Close database connection
 	database.Close()
-}
\ No newline at end of file
+}